@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"compactmapper/internal/fsutil"
 	"compactmapper/las"
 )
 
@@ -18,12 +26,47 @@ type Point struct {
 	TargetPass int
 }
 
+// fileResult is one file's conversion outcome, aggregated into runSummary
+// and serialized to run.json alongside the existing err.log.
+type fileResult struct {
+	File       string `json:"file"`
+	DurationMS int64  `json:"duration_ms"`
+	Points     int    `json:"points"`
+	Error      string `json:"error,omitempty"`
+}
+
+// runSummary is the JSON document written to run.json, recording every
+// input file's result from one CLI invocation in input order.
+type runSummary struct {
+	Files     []fileResult `json:"files"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// errorLogMu serializes writes to the shared errorLog file, since
+// convertCSVtoLAS now runs concurrently across convertAll's worker pool.
+var errorLogMu sync.Mutex
+
+// logError writes a formatted line to errorLog if it's set, guarded by
+// errorLogMu so concurrent workers don't interleave partial lines.
+func logError(errorLog *os.File, format string, args ...interface{}) {
+	if errorLog == nil {
+		return
+	}
+	errorLogMu.Lock()
+	defer errorLogMu.Unlock()
+	fmt.Fprintf(errorLog, format, args...)
+}
+
 func main() {
 	inputDir := flag.String("input", "", "Input directory containing CSV files")
 	outputDir := flag.String("output", "", "Output directory for LAS files")
 	// skipErrors allows processing to continue when encountering malformed CSV data
 	// All errors are logged to err.log in the output directory for later review
 	skipErrors := flag.Bool("skip-errors", false, "Skip rows with errors and continue processing (errors logged to err.log)")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of files to convert concurrently")
+	timeout := flag.Duration("timeout", 30*time.Second, "Per-file conversion timeout (e.g. 30s, 2m); a file that exceeds it is recorded as failed and the worker moves on")
+	compressFlag := flag.String("compress", "", "Output compression: \"\" (default, plain .las) or \"laz\" (requires laszip or pdal on PATH)")
 	flag.Parse()
 
 	if *inputDir == "" || *outputDir == "" {
@@ -33,23 +76,44 @@ func main() {
 		fmt.Println("\nExample:")
 		fmt.Println("  compactmapper-cli -input ./testdata -output ./output")
 		fmt.Println("  compactmapper-cli -input ./testdata -output ./output -skip-errors")
+		fmt.Println("  compactmapper-cli -input ./testdata -output ./output -workers 8 -timeout 1m")
+		fmt.Println("  compactmapper-cli -input ./testdata -output ./output -compress laz")
+		os.Exit(1)
+	}
+
+	var compress las.Compression
+	switch *compressFlag {
+	case "", "none":
+		// zero value: las.CompressionNone
+	case "laz":
+		compress = las.CompressionLAZ
+	default:
+		fmt.Fprintf(os.Stderr, "Error: -compress must be \"\" or \"laz\", got %q\n", *compressFlag)
 		os.Exit(1)
 	}
 
+	// fsys is the filesystem the directory checks, the CSV scan and each
+	// file's conversion go through, so the conversion logic can be
+	// exercised against an in-memory fsutil.MemFilesystem in tests instead
+	// of always touching real disk - the same pattern las.Writer and
+	// converter.ConvertDirectoryFS already use. err.log and run.json are
+	// written with os directly.
+	fsys := fsutil.OS
+
 	// Check input directory exists
-	if _, err := os.Stat(*inputDir); os.IsNotExist(err) {
+	if _, err := fsys.Stat(*inputDir); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: Input directory does not exist: %s\n", *inputDir)
 		os.Exit(1)
 	}
 
 	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+	if err := fsys.MkdirAll(*outputDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Find CSV files
-	files, err := filepath.Glob(filepath.Join(*inputDir, "*.csv"))
+	files, err := fsys.Glob(filepath.Join(*inputDir, "*.csv"))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning directory: %v\n", err)
 		os.Exit(1)
@@ -60,68 +124,194 @@ func main() {
 		os.Exit(0)
 	}
 
-	fmt.Printf("Found %d CSV file(s) to convert\n\n", len(files))
+	fmt.Printf("Found %d CSV file(s) to convert (%d worker(s), %s timeout)\n\n", len(files), *workers, *timeout)
 
 	// Setup error logging if skip-errors is enabled
 	// This provides an audit trail of all data quality issues encountered during processing
 	var errorLog *os.File
+	errLogPath := filepath.Join(*outputDir, "err.log")
 	if *skipErrors {
-		errorLogPath := filepath.Join(*outputDir, "err.log")
 		var err error
-		errorLog, err = os.Create(errorLogPath)
+		errorLog, err = os.Create(errLogPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating error log file: %v\n", err)
 			os.Exit(1)
 		}
 		defer errorLog.Close()
-		fmt.Printf("Error logging enabled: %s\n\n", errorLogPath)
+		fmt.Printf("Error logging enabled: %s\n\n", errLogPath)
 	}
 
-	// Convert each file
-	successCount := 0
-	for i, csvFile := range files {
-		fmt.Printf("[%d/%d] Converting %s...", i+1, len(files), filepath.Base(csvFile))
+	summary := convertAll(fsys, files, *outputDir, *skipErrors, errorLog, *workers, *timeout, compress)
 
-		if err := convertCSVtoLAS(csvFile, *outputDir, *skipErrors, errorLog); err != nil {
-			fmt.Printf(" FAILED\n  Error: %v\n", err)
-			if errorLog != nil {
-				fmt.Fprintf(errorLog, "File: %s - Error: %v\n", filepath.Base(csvFile), err)
-			}
-			if !*skipErrors {
-				continue
+	summaryPath := filepath.Join(*outputDir, "run.json")
+	if err := writeRunSummary(summaryPath, summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing run summary: %v\n", err)
+	}
+
+	fmt.Printf("\nConversion complete! %d/%d files successfully converted\n", summary.Succeeded, len(files))
+	fmt.Printf("Run summary written to: %s\n", summaryPath)
+	if *skipErrors && errorLog != nil {
+		fmt.Printf("Errors logged to: %s\n", errLogPath)
+	}
+}
+
+// convertAll converts files through a worker pool sized by workers
+// (defaulting to runtime.NumCPU() when non-positive, the same fallback
+// SortCSVDirectoryWithOptions uses for its own Concurrency option),
+// bounding each file's conversion by timeout so one oversized CSV can't
+// stall the whole batch. It prints a progress bar to stderr as files
+// complete and returns a runSummary with one fileResult per input file,
+// in input order.
+func convertAll(fsys fsutil.Filesystem, files []string, outputDir string, skipErrors bool, errorLog *os.File, workers int, timeout time.Duration, compress las.Compression) runSummary {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	type job struct {
+		index int
+		path  string
+	}
+
+	results := make([]fileResult, len(files))
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	var completed int32
+
+	worker := func() {
+		defer wg.Done()
+		for j := range jobs {
+			result := convertFileWithTimeout(fsys, j.path, outputDir, skipErrors, errorLog, timeout, compress)
+			results[j.index] = result
+			if result.Error != "" {
+				logError(errorLog, "File: %s - Error: %s\n", result.File, result.Error)
 			}
+			n := atomic.AddInt32(&completed, 1)
+			printProgress(int(n), len(files))
 		}
+	}
 
-		fmt.Printf(" OK\n")
-		successCount++
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker()
+	}
+	for i, path := range files {
+		jobs <- job{index: i, path: path}
 	}
+	close(jobs)
+	wg.Wait()
+	fmt.Fprintln(os.Stderr)
 
-	fmt.Printf("\nConversion complete! %d/%d files successfully converted\n", successCount, len(files))
-	if *skipErrors && errorLog != nil {
-		fmt.Printf("Errors logged to: %s\n", filepath.Join(*outputDir, "err.log"))
+	summary := runSummary{Files: results}
+	for _, r := range results {
+		fmt.Printf("%s: %s\n", r.File, resultLine(r))
+		if r.Error == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
 	}
+	return summary
 }
 
-// convertCSVtoLAS converts a single CSV file to LAS format
+// resultLine renders a single fileResult as a short human-readable
+// status line for stdout.
+func resultLine(r fileResult) string {
+	duration := time.Duration(r.DurationMS) * time.Millisecond
+	if r.Error != "" {
+		return fmt.Sprintf("FAILED (%s) - %s", duration, r.Error)
+	}
+	return fmt.Sprintf("OK (%s, %d points)", duration, r.Points)
+}
+
+// convertFileWithTimeout runs convertCSVtoLAS for one file in its own
+// goroutine, reporting a timeout error if it doesn't finish within
+// timeout instead of blocking the worker pool on a single huge file.
+// convertCSVtoLAS has no cancellation points of its own, so a straggler
+// keeps running in the background after its worker moves on to the next
+// job - timeout bounds how long the batch waits on it, not the work
+// itself.
+func convertFileWithTimeout(fsys fsutil.Filesystem, path, outputFolder string, skipErrors bool, errorLog *os.File, timeout time.Duration, compress las.Compression) fileResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	name := filepath.Base(path)
+	start := time.Now()
+	done := make(chan fileResult, 1)
+	go func() {
+		points, err := convertCSVtoLAS(fsys, path, outputFolder, skipErrors, errorLog, compress)
+		result := fileResult{File: name, Points: points}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		result.DurationMS = time.Since(start).Milliseconds()
+		return result
+	case <-ctx.Done():
+		return fileResult{
+			File:       name,
+			DurationMS: time.Since(start).Milliseconds(),
+			Error:      fmt.Sprintf("timed out after %s", timeout),
+		}
+	}
+}
+
+// printProgress renders a "[====>     ] done/total" bar to stderr,
+// overwriting the previous line with \r so the batch's progress doesn't
+// scroll the terminal one line per file.
+func printProgress(done, total int) {
+	const width = 30
+	filled := done * width / total
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	fmt.Fprintf(os.Stderr, "\r[%s] %d/%d", bar, done, total)
+}
+
+// writeRunSummary writes summary as indented JSON to path, giving
+// callers a machine-readable per-file record (duration, point count,
+// error) alongside the human-readable stdout/stderr output.
+func writeRunSummary(path string, summary runSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding run summary: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing run summary: %v", err)
+	}
+	return nil
+}
+
+// convertCSVtoLAS converts a single CSV file to LAS format, reading and
+// writing through fsys instead of calling os.Open/os.Create directly -
+// letting callers substitute an fsutil.MemFilesystem in tests. It
+// returns the number of points written so callers can report it in a
+// summary.
 // skipErrors: when true, rows with parsing errors are skipped and logged instead of failing
 // errorLog: optional file handle for logging errors (required when skipErrors is true)
 // This function is used by the CLI tool; the main converter package is used by GUI and pipeline
-func convertCSVtoLAS(csvPath, outputFolder string, skipErrors bool, errorLog *os.File) error {
+// compress selects whether the output is a plain .las file (las.CompressionNone)
+// or a LAZ-compressed .laz file (las.CompressionLAZ); see las.Writer.WriteLAZ.
+func convertCSVtoLAS(fsys fsutil.Filesystem, csvPath, outputFolder string, skipErrors bool, errorLog *os.File, compress las.Compression) (int, error) {
 	// Read CSV file
-	file, err := os.Open(csvPath)
+	file, err := fsys.Open(csvPath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
 	records, err := reader.ReadAll()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if len(records) < 2 {
-		return fmt.Errorf("CSV file is empty or has no data rows")
+		return 0, fmt.Errorf("CSV file is empty or has no data rows")
 	}
 
 	// Parse header
@@ -135,7 +325,7 @@ func convertCSVtoLAS(csvPath, outputFolder string, skipErrors bool, errorLog *os
 	required := []string{"CellE_m", "CellN_m", "Elevation_m", "PassCount", "TargPassCount"}
 	for _, col := range required {
 		if _, ok := colMap[col]; !ok {
-			return fmt.Errorf("missing required column: %s", col)
+			return 0, fmt.Errorf("missing required column: %s", col)
 		}
 	}
 
@@ -148,57 +338,47 @@ func convertCSVtoLAS(csvPath, outputFolder string, skipErrors bool, errorLog *os
 		x, err := strconv.ParseFloat(row[colMap["CellE_m"]], 64)
 		if err != nil {
 			if skipErrors {
-				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: invalid CellE_m value: %v\n", filepath.Base(csvPath), i+1, err)
-				}
+				logError(errorLog, "File: %s, Row %d: invalid CellE_m value: %v\n", filepath.Base(csvPath), i+1, err)
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("row %d: invalid CellE_m value: %v", i+1, err)
+			return 0, fmt.Errorf("row %d: invalid CellE_m value: %v", i+1, err)
 		}
 		y, err := strconv.ParseFloat(row[colMap["CellN_m"]], 64)
 		if err != nil {
 			if skipErrors {
-				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: invalid CellN_m value: %v\n", filepath.Base(csvPath), i+1, err)
-				}
+				logError(errorLog, "File: %s, Row %d: invalid CellN_m value: %v\n", filepath.Base(csvPath), i+1, err)
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("row %d: invalid CellN_m value: %v", i+1, err)
+			return 0, fmt.Errorf("row %d: invalid CellN_m value: %v", i+1, err)
 		}
 		z, err := strconv.ParseFloat(row[colMap["Elevation_m"]], 64)
 		if err != nil {
 			if skipErrors {
-				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: invalid Elevation_m value: %v\n", filepath.Base(csvPath), i+1, err)
-				}
+				logError(errorLog, "File: %s, Row %d: invalid Elevation_m value: %v\n", filepath.Base(csvPath), i+1, err)
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("row %d: invalid Elevation_m value: %v", i+1, err)
+			return 0, fmt.Errorf("row %d: invalid Elevation_m value: %v", i+1, err)
 		}
 		passCount, err := strconv.Atoi(row[colMap["PassCount"]])
 		if err != nil {
 			if skipErrors {
-				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: invalid PassCount value: %v\n", filepath.Base(csvPath), i+1, err)
-				}
+				logError(errorLog, "File: %s, Row %d: invalid PassCount value: %v\n", filepath.Base(csvPath), i+1, err)
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("row %d: invalid PassCount value: %v", i+1, err)
+			return 0, fmt.Errorf("row %d: invalid PassCount value: %v", i+1, err)
 		}
 		targPass, err := strconv.Atoi(row[colMap["TargPassCount"]])
 		if err != nil {
 			if skipErrors {
-				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: invalid TargPassCount value: %v\n", filepath.Base(csvPath), i+1, err)
-				}
+				logError(errorLog, "File: %s, Row %d: invalid TargPassCount value: %v\n", filepath.Base(csvPath), i+1, err)
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("row %d: invalid TargPassCount value: %v", i+1, err)
+			return 0, fmt.Errorf("row %d: invalid TargPassCount value: %v", i+1, err)
 		}
 
 		// Determine color based on pass count
@@ -219,14 +399,19 @@ func convertCSVtoLAS(csvPath, outputFolder string, skipErrors bool, errorLog *os
 		})
 	}
 
-	if skipErrors && skippedRows > 0 && errorLog != nil {
-		fmt.Fprintf(errorLog, "File: %s - Total skipped rows: %d\n", filepath.Base(csvPath), skippedRows)
+	if skipErrors && skippedRows > 0 {
+		logError(errorLog, "File: %s - Total skipped rows: %d\n", filepath.Base(csvPath), skippedRows)
 	}
 
 	// Create LAS file
-	outputPath := filepath.Join(outputFolder, filepath.Base(csvPath[:len(csvPath)-4]+".las"))
+	ext := ".las"
+	if compress == las.CompressionLAZ {
+		ext = ".laz"
+	}
+	outputPath := filepath.Join(outputFolder, filepath.Base(csvPath[:len(csvPath)-4]+ext))
 
-	writer := las.NewWriter()
+	writer := las.NewWriterFS(fsys)
+	writer.SetCompression(compress)
 
 	for _, pt := range points {
 		writer.AddPoint(las.Point{
@@ -241,5 +426,8 @@ func convertCSVtoLAS(csvPath, outputFolder string, skipErrors bool, errorLog *os
 		})
 	}
 
-	return writer.Write(outputPath)
+	if err := writer.Write(outputPath); err != nil {
+		return 0, err
+	}
+	return len(points), nil
 }
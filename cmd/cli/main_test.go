@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// TestConvertCSVtoLASMemFilesystem exercises convertCSVtoLAS entirely
+// in-memory, with no real disk I/O.
+func TestConvertCSVtoLASMemFilesystem(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+200.3,100.5,5.2,2,4
+`))
+
+	points, err := convertCSVtoLAS(fsys, "input/test.csv", "output", false, nil, las.CompressionNone)
+	if err != nil {
+		t.Fatalf("convertCSVtoLAS failed: %v", err)
+	}
+	if points != 1 {
+		t.Errorf("points = %d, want 1", points)
+	}
+
+	if _, err := fsys.Stat("output/test.las"); err != nil {
+		t.Errorf("expected output/test.las to exist: %v", err)
+	}
+}
+
+// TestConvertCSVtoLASMalformedInput verifies malformed CSV data still
+// produces a descriptive error against the in-memory filesystem.
+func TestConvertCSVtoLASMalformedInput(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/bad.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+not-a-number,100.5,5.2,2,4
+`))
+
+	_, err := convertCSVtoLAS(fsys, "input/bad.csv", "output", false, nil, las.CompressionNone)
+	if err == nil {
+		t.Fatal("expected an error for malformed CellE_m value, got nil")
+	}
+}
+
+// TestConvertAllAggregatesResults runs several files through convertAll's
+// worker pool and checks the returned summary matches each file's actual
+// outcome, in input order.
+func TestConvertAllAggregatesResults(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/good1.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+200.3,100.5,5.2,2,4
+`))
+	fsys.WriteFile("input/good2.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+10,20,3,1,1
+30,40,6,2,1
+`))
+	fsys.WriteFile("input/bad.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+not-a-number,100.5,5.2,2,4
+`))
+
+	files := []string{"input/good1.csv", "input/good2.csv", "input/bad.csv"}
+	summary := convertAll(fsys, files, "output", false, nil, 2, time.Second, las.CompressionNone)
+
+	if summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Fatalf("summary = %+v, want 2 succeeded / 1 failed", summary)
+	}
+	if len(summary.Files) != len(files) {
+		t.Fatalf("len(summary.Files) = %d, want %d", len(summary.Files), len(files))
+	}
+	if summary.Files[0].Points != 1 || summary.Files[0].Error != "" {
+		t.Errorf("good1.csv result = %+v, want 1 point and no error", summary.Files[0])
+	}
+	if summary.Files[1].Points != 2 || summary.Files[1].Error != "" {
+		t.Errorf("good2.csv result = %+v, want 2 points and no error", summary.Files[1])
+	}
+	if summary.Files[2].Error == "" {
+		t.Errorf("bad.csv result = %+v, want a non-empty error", summary.Files[2])
+	}
+}
+
+// TestConvertAllTimeout verifies a file that doesn't finish within the
+// given timeout is recorded as a timeout failure rather than blocking
+// the batch indefinitely.
+func TestConvertAllTimeout(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount
+200.3,100.5,5.2,2,4
+`))
+
+	summary := convertAll(fsys, []string{"input/test.csv"}, "output", false, nil, 1, 0, las.CompressionNone)
+
+	if summary.Failed != 1 || summary.Succeeded != 0 {
+		t.Fatalf("summary = %+v, want 1 failed / 0 succeeded", summary)
+	}
+	if summary.Files[0].Error == "" {
+		t.Fatal("expected a timeout error, got none")
+	}
+}
@@ -0,0 +1,37 @@
+// Command compactmapper-serve serves a browser-based 3D preview of a
+// directory of .las files produced by compactmapper, so a field crew can
+// check pass-count coverage without installing CloudCompare.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/internal/previewserver"
+)
+
+func main() {
+	dir := flag.String("dir", "", "Directory of converted .las files to preview (required)")
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	maxPoints := flag.Int("max-points", previewserver.DefaultMaxPoints, "Voxel-decimate point clouds larger than this before sending them to the browser")
+	flag.Parse()
+
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: -dir is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if _, err := fsutil.OS.Stat(*dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := previewserver.NewServer(fsutil.OS, *dir, *maxPoints)
+	fmt.Printf("Serving preview of %s on %s\n", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, srv.Handler()))
+}
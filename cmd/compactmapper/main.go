@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"compactmapper/internal/converter"
+	"compactmapper/internal/exporter/rrd"
+	"compactmapper/internal/fsutil"
 	"compactmapper/internal/gui"
 	"compactmapper/internal/sorter"
+	"compactmapper/las"
 )
 
 // version is set via ldflags during build (see Makefile)
@@ -21,6 +27,17 @@ func main() {
 	sortOnlyFlag := flag.Bool("sort-only", false, "Only sort CSV files (skip LAS conversion)")
 	convertOnlyFlag := flag.Bool("convert-only", false, "Only convert CSV to LAS (assume already sorted)")
 	skipErrorsFlag := flag.Bool("skip-errors", false, "Skip rows with errors and continue processing (errors logged to err.log)")
+	paletteFlag := flag.String("palette", "", "Path to a JSON palette file selecting a color-mapping strategy (default: pass-count classifier)")
+	lasFormatFlag := flag.String("las-format", "1.2", "LAS output format: \"1.2\" (legacy, point format 3) or \"1.4\" (modern, point format 7)")
+	epsgFlag := flag.Int("epsg", 0, "EPSG code to tag output LAS files with (GeoKeyDirectoryTag VLR + .prj sidecar); 0 disables CRS tagging")
+	sourceEPSGFlag := flag.Int("source-epsg", 0, "EPSG code of the input CellE_m/CellN_m coordinates; if set and different from --epsg, points are reprojected via cs2cs")
+	rrdOutFlag := flag.String("rrd-out", "", "Directory to write a Round-Robin Database per Date/Design/Amp/Machine group for trending CMV/MDP/EVIB1/PassCount/Speed/Temp (requires rrdtool on PATH); empty disables RRD export")
+	encodingFlag := flag.String("encoding", "", "Input CSV text encoding: \"\" or \"utf8\" (default), \"auto\" (sniff a byte-order mark), \"cp1252\", \"utf16le\", or \"utf16be\"")
+	workersFlag := flag.Int("workers", 0, "Number of files to sort/convert concurrently (0 selects the number of CPUs)")
+	timeoutFlag := flag.Duration("timeout", 0, "Per-file conversion timeout (e.g. 30s, 2m); 0 disables the timeout")
+	compressFlag := flag.String("compress", "", "Output compression: \"\" (default, plain .las) or \"laz\" (requires laszip or pdal on PATH)")
+	tiledFlag := flag.Bool("tiled", false, "Convert each file to an octree-tiled directory of plain LAS files plus a hierarchy.json manifest instead of one flat LAS file, for viewers that fetch only the visible tiles. This is a from-scratch tile format, not COPC or any other existing spec - see internal/tiledpoints' package doc")
+	tiledMaxPointsFlag := flag.Int("tiled-max-points", 0, "Max points per octree tile when --tiled is set (0 selects the package default, 100000)")
 	versionFlag := flag.Bool("version", false, "Show version information")
 	guiFlag := flag.Bool("gui", false, "Launch GUI (default if no flags provided)")
 
@@ -50,6 +67,20 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  compactmapper --input ./sorted --output ./las --convert-only\n\n")
 		fmt.Fprintf(os.Stderr, "  # Skip errors and log them\n")
 		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --skip-errors\n\n")
+		fmt.Fprintf(os.Stderr, "  # Color points with a custom palette\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --palette scheme.json\n\n")
+		fmt.Fprintf(os.Stderr, "  # Tag output with a coordinate system and reproject from WGS84\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --epsg 32610 --source-epsg 4326\n\n")
+		fmt.Fprintf(os.Stderr, "  # Also export per-group RRD trending databases\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --rrd-out ./results/rrd\n\n")
+		fmt.Fprintf(os.Stderr, "  # Ingest a cp1252 export from older Windows tooling\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --encoding cp1252\n\n")
+		fmt.Fprintf(os.Stderr, "  # Bound conversion concurrency and per-file runtime on a huge batch\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --workers 8 --timeout 30s\n\n")
+		fmt.Fprintf(os.Stderr, "  # Emit LAZ-compressed output instead of plain LAS\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --compress laz\n\n")
+		fmt.Fprintf(os.Stderr, "  # Emit octree-tiled directories instead of flat LAS files\n")
+		fmt.Fprintf(os.Stderr, "  compactmapper --input ./csvdata --output ./results --tiled --tiled-max-points 50000\n\n")
 		fmt.Fprintf(os.Stderr, "Output Structure:\n")
 		fmt.Fprintf(os.Stderr, "  output/\n")
 		fmt.Fprintf(os.Stderr, "    csv/  - Sorted CSV files grouped by Date/Design/Amplitude\n")
@@ -91,20 +122,64 @@ func main() {
 
 	isDirectory := inputInfo.IsDir()
 
+	var lasFormat converter.LASFormat
+	switch *lasFormatFlag {
+	case "1.2":
+		// zero value: legacy LAS 1.2, point format 3
+	case "1.4":
+		lasFormat = converter.LASFormat{Version: las.Version{Major: 1, Minor: 4}, PointFormat: 7}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --las-format must be \"1.2\" or \"1.4\", got %q\n", *lasFormatFlag)
+		os.Exit(1)
+	}
+
+	// Load a custom color palette if one was given; otherwise fall back to
+	// the default pass-count classifier.
+	var colorMapper converter.ColorMapper
+	if *paletteFlag != "" {
+		data, err := os.ReadFile(*paletteFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading palette file: %v\n", err)
+			os.Exit(1)
+		}
+		colorMapper, err = converter.LoadPalette(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading palette: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	crs := las.CRS{EPSG: *epsgFlag}
+
+	var compress las.Compression
+	switch *compressFlag {
+	case "", "none":
+		// zero value: CompressionNone
+	case "laz":
+		compress = las.CompressionLAZ
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --compress must be \"\" or \"laz\", got %q\n", *compressFlag)
+		os.Exit(1)
+	}
+
 	// Determine what to do
 	if *convertOnlyFlag {
 		// Only convert CSV to LAS
-		runConvertOnly(*inputFlag, *outputFlag)
+		if *tiledFlag {
+			runTiledConvert(*inputFlag, *outputFlag, isDirectory, *skipErrorsFlag, colorMapper, *tiledMaxPointsFlag)
+		} else {
+			runConvertOnly(*inputFlag, *outputFlag, *skipErrorsFlag, colorMapper, lasFormat, crs, *sourceEPSGFlag, *workersFlag, *timeoutFlag, compress)
+		}
 	} else if *sortOnlyFlag {
 		// Only sort CSV
-		runSortOnly(*inputFlag, *outputFlag, isDirectory, *skipErrorsFlag)
+		runSortOnly(*inputFlag, *outputFlag, isDirectory, *skipErrorsFlag, *rrdOutFlag, *encodingFlag, *workersFlag)
 	} else {
 		// Full pipeline: sort + convert
-		runFullPipeline(*inputFlag, *outputFlag, isDirectory, *skipErrorsFlag)
+		runFullPipeline(*inputFlag, *outputFlag, isDirectory, *skipErrorsFlag, colorMapper, lasFormat, crs, *sourceEPSGFlag, *rrdOutFlag, *encodingFlag, *workersFlag, *timeoutFlag, compress, *tiledFlag, *tiledMaxPointsFlag)
 	}
 }
 
-func runSortOnly(input, output string, isDirectory bool, skipErrors bool) {
+func runSortOnly(input, output string, isDirectory bool, skipErrors bool, rrdOut, encoding string, workers int) {
 	fmt.Println("Starting CSV sorting...")
 
 	// Setup error logging if skip-errors is enabled
@@ -121,13 +196,24 @@ func runSortOnly(input, output string, isDirectory bool, skipErrors bool) {
 		fmt.Printf("Error logging enabled: %s\n", errorLogPath)
 	}
 
+	rrdExporter := setupRRDExporter(rrdOut)
+	// rrdExporter is only wrapped as a sorter.Exporter when non-nil:
+	// assigning a nil *rrd.Exporter to an interface-typed field directly
+	// would produce a non-nil interface wrapping a nil pointer, which
+	// would then fail every "Exporter != nil" check inside sorter.
+	var exporter sorter.Exporter
+	if rrdExporter != nil {
+		exporter = rrdExporter
+		defer rrdExporter.Close()
+	}
+
 	var err error
 	if isDirectory {
 		fmt.Printf("Processing directory: %s\n", input)
-		err = sorter.SortCSVDirectory(input, output, skipErrors, errorLog)
+		err = sorter.SortCSVDirectoryWithOptions(input, output, skipErrors, errorLog, sorter.SortDirectoryOptions{Exporter: exporter, Encoding: encoding, Concurrency: workers})
 	} else {
 		fmt.Printf("Processing file: %s\n", input)
-		err = sorter.SortCSV(input, output, skipErrors, errorLog)
+		err = sorter.SortCSVWithOptions(input, output, skipErrors, errorLog, sorter.SortOptions{Exporter: exporter, Encoding: encoding})
 	}
 
 	if err != nil && !skipErrors {
@@ -140,24 +226,186 @@ func runSortOnly(input, output string, isDirectory bool, skipErrors bool) {
 	if skipErrors && errorLog != nil {
 		fmt.Printf("  Errors logged to: %s\n", filepath.Join(output, "err.log"))
 	}
+	if rrdExporter != nil {
+		fmt.Printf("  RRD databases: %s\n", rrdOut)
+	}
+}
+
+// setupRRDExporter builds the RRD exporter wired into sorting when
+// rrdOut is set, exiting the process with a clear error if rrdtool
+// isn't available rather than silently skipping RRD export.
+func setupRRDExporter(rrdOut string) *rrd.Exporter {
+	if rrdOut == "" {
+		return nil
+	}
+	exporter, err := rrd.NewExporter(rrdOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting up RRD export: %v\n", err)
+		os.Exit(1)
+	}
+	return exporter
 }
 
-func runConvertOnly(input, output string) {
+func runConvertOnly(input, output string, skipErrors bool, colorMapper converter.ColorMapper, lasFormat converter.LASFormat, crs las.CRS, sourceEPSG, workers int, timeout time.Duration, compress las.Compression) {
 	fmt.Println("Starting CSV to LAS conversion...")
 	fmt.Printf("Input directory: %s\n", input)
 
-	count, err := converter.ConvertDirectory(input, output)
+	// Setup error logging if skip-errors is enabled
+	var errorLog *os.File
+	if skipErrors {
+		errorLogPath := filepath.Join(output, "err.log")
+		var err error
+		errorLog, err = os.Create(errorLogPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating error log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer errorLog.Close()
+		fmt.Printf("Error logging enabled: %s\n", errorLogPath)
+	}
+
+	results, err := converter.ConvertDirectory(context.Background(), input, output, converter.ConvertOptions{
+		SkipErrors:  skipErrors,
+		ErrorLog:    errorLog,
+		ColorMapper: colorMapper,
+		LASFormat:   lasFormat,
+		CRS:         crs,
+		SourceEPSG:  sourceEPSG,
+		Workers:     workers,
+		Timeout:     timeout,
+		Compress:    compress,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error during conversion: %v\n", err)
 		os.Exit(1)
 	}
 
+	writeConversionSummary(output, results)
+
+	fmt.Printf("\n✓ Conversion complete!\n")
+	fmt.Printf("  Processed: %d files\n", len(results))
+	fmt.Printf("  Output: %s\n", output)
+}
+
+// runTiledConvert converts input (a single file or every *.csv in a
+// directory) to octree-tiled directories via converter.ConvertCSVToTiledFS
+// instead of ConvertDirectory's flat .las output. It runs sequentially
+// rather than through ConvertDirectory's worker pool: each file needs its
+// own tile subdirectory (not one shared output path), and --tiled is a
+// secondary output mode rather than the default high-throughput path
+// that pool was built for.
+func runTiledConvert(input, output string, isDirectory bool, skipErrors bool, colorMapper converter.ColorMapper, maxPointsPerNode int) {
+	fmt.Println("Starting CSV to octree-tiled conversion...")
+	fmt.Fprintln(os.Stderr, "Note: --tiled writes a directory of plain .las tiles plus hierarchy.json - a custom, non-standard tile layout (see internal/tiledpoints' package doc), not COPC or any other existing point-cloud spec, so it won't open in PDAL, untwine, copc-lib, or any HTTP-range COPC client.")
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	var errorLog *os.File
+	if skipErrors {
+		errorLogPath := filepath.Join(output, "err.log")
+		var err error
+		errorLog, err = os.Create(errorLogPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating error log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer errorLog.Close()
+		fmt.Printf("Error logging enabled: %s\n", errorLogPath)
+	}
+
+	var files []string
+	if isDirectory {
+		matches, err := filepath.Glob(filepath.Join(input, "*.csv"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning directory: %v\n", err)
+			os.Exit(1)
+		}
+		files = matches
+	} else {
+		files = []string{input}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no CSV files found in %s\n", input)
+		os.Exit(1)
+	}
+
+	succeeded, failed := 0, 0
+	for _, csvFile := range files {
+		fmt.Printf("Converting %s...\n", filepath.Base(csvFile))
+		points, err := converter.ConvertCSVToTiledFS(fsutil.OS, csvFile, output, skipErrors, errorLog, nil, colorMapper, maxPointsPerNode)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "  Error converting %s: %v\n", filepath.Base(csvFile), err)
+			if !skipErrors {
+				os.Exit(1)
+			}
+			continue
+		}
+		succeeded++
+		fmt.Printf("  Wrote %d points across octree tiles\n", points)
+	}
+
 	fmt.Printf("\n✓ Conversion complete!\n")
-	fmt.Printf("  Processed: %d files\n", count)
+	fmt.Printf("  Succeeded: %d, Failed: %d\n", succeeded, failed)
 	fmt.Printf("  Output: %s\n", output)
 }
 
-func runFullPipeline(input, output string, isDirectory bool, skipErrors bool) {
+// conversionSummary is the JSON document written to run.json, recording
+// every converted file's duration, point count, and error (if any)
+// alongside the human-readable stdout summary.
+type conversionSummary struct {
+	Files     []conversionFileResult `json:"files"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+}
+
+// conversionFileResult is converter.FileResult in JSON-serializable
+// form - converter.FileResult.Err is the error interface, which
+// encoding/json can't marshal into anything useful on its own.
+type conversionFileResult struct {
+	File       string `json:"file"`
+	DurationMS int64  `json:"duration_ms"`
+	Points     int    `json:"points"`
+	Error      string `json:"error,omitempty"`
+}
+
+// writeConversionSummary writes results as run.json in outputDir. A
+// failure to write it is reported but doesn't abort the run - the
+// conversion itself already succeeded or failed on its own terms.
+func writeConversionSummary(outputDir string, results []converter.FileResult) {
+	summary := conversionSummary{Files: make([]conversionFileResult, len(results))}
+	for i, r := range results {
+		fr := conversionFileResult{
+			File:       filepath.Base(r.Path),
+			DurationMS: r.Duration.Milliseconds(),
+			Points:     r.PointsWritten,
+		}
+		if r.Err != nil {
+			fr.Error = r.Err.Error()
+			summary.Failed++
+		} else {
+			summary.Succeeded++
+		}
+		summary.Files[i] = fr
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding run summary: %v\n", err)
+		return
+	}
+	summaryPath := filepath.Join(outputDir, "run.json")
+	if err := os.WriteFile(summaryPath, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing run summary: %v\n", err)
+		return
+	}
+	fmt.Printf("  Run summary: %s\n", summaryPath)
+}
+
+func runFullPipeline(input, output string, isDirectory bool, skipErrors bool, colorMapper converter.ColorMapper, lasFormat converter.LASFormat, crs las.CRS, sourceEPSG int, rrdOut, encoding string, workers int, timeout time.Duration, compress las.Compression, tiled bool, tiledMaxPointsPerNode int) {
 	// Setup error logging if skip-errors is enabled
 	var errorLog *os.File
 	if skipErrors {
@@ -172,6 +420,15 @@ func runFullPipeline(input, output string, isDirectory bool, skipErrors bool) {
 		fmt.Printf("Error logging enabled: %s\n\n", errorLogPath)
 	}
 
+	rrdExporter := setupRRDExporter(rrdOut)
+	// See runSortOnly's identical guard for why this can't be a direct
+	// assignment into the interface-typed SortOptions/SortDirectoryOptions field.
+	var exporter sorter.Exporter
+	if rrdExporter != nil {
+		exporter = rrdExporter
+		defer rrdExporter.Close()
+	}
+
 	// Step 1: Sort CSV files
 	sortedDir := filepath.Join(output, "csv")
 	fmt.Println("Step 1/2: Sorting CSV files...")
@@ -179,10 +436,10 @@ func runFullPipeline(input, output string, isDirectory bool, skipErrors bool) {
 	var err error
 	if isDirectory {
 		fmt.Printf("Processing directory: %s\n", input)
-		err = sorter.SortCSVDirectory(input, sortedDir, skipErrors, errorLog)
+		err = sorter.SortCSVDirectoryWithOptions(input, sortedDir, skipErrors, errorLog, sorter.SortDirectoryOptions{Exporter: exporter, Encoding: encoding, Concurrency: workers})
 	} else {
 		fmt.Printf("Processing file: %s\n", input)
-		err = sorter.SortCSV(input, sortedDir, skipErrors, errorLog)
+		err = sorter.SortCSVWithOptions(input, sortedDir, skipErrors, errorLog, sorter.SortOptions{Exporter: exporter, Encoding: encoding})
 	}
 
 	if err != nil && !skipErrors {
@@ -191,21 +448,42 @@ func runFullPipeline(input, output string, isDirectory bool, skipErrors bool) {
 	}
 
 	fmt.Printf("✓ Sorted CSV files: %s\n\n", sortedDir)
+	if rrdExporter != nil {
+		fmt.Printf("✓ RRD databases: %s\n\n", rrdOut)
+	}
 
-	// Step 2: Convert to LAS
+	// Step 2: Convert to LAS (or, with --tiled, octree-tiled directories)
 	lasDir := filepath.Join(output, "las")
+	if tiled {
+		fmt.Println("Step 2/2: Converting to octree-tiled output...")
+		runTiledConvert(sortedDir, lasDir, true, skipErrors, colorMapper, tiledMaxPointsPerNode)
+		return
+	}
+
 	fmt.Println("Step 2/2: Converting to LAS...")
 
-	count, err := converter.ConvertDirectory(sortedDir, lasDir)
+	results, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, converter.ConvertOptions{
+		SkipErrors:  skipErrors,
+		ErrorLog:    errorLog,
+		ColorMapper: colorMapper,
+		LASFormat:   lasFormat,
+		CRS:         crs,
+		SourceEPSG:  sourceEPSG,
+		Workers:     workers,
+		Timeout:     timeout,
+		Compress:    compress,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error during conversion: %v\n", err)
 		os.Exit(1)
 	}
 
+	writeConversionSummary(lasDir, results)
+
 	fmt.Printf("\n🎉 Processing complete!\n")
 	fmt.Printf("  Sorted CSV: %s\n", sortedDir)
 	fmt.Printf("  LAS files:  %s\n", lasDir)
-	fmt.Printf("  Total:      %d files\n", count)
+	fmt.Printf("  Total:      %d files\n", len(results))
 	if skipErrors && errorLog != nil {
 		fmt.Printf("  Errors logged to: %s\n", filepath.Join(output, "err.log"))
 	}
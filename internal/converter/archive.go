@@ -0,0 +1,272 @@
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// groupFilenamePattern extracts the Date/DesignName/Amp a converted LAS
+// file's name encodes, mirroring sorter's
+// "{date}design{design}amp{amp}.ext" layout (generateFilename +
+// sanitizeFilename).
+var groupFilenamePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})design(.*)amp(.*)\.las$`)
+
+// ArchiveGroupManifest is one Date/DesignName/LastAmp group's entry in a
+// bundled archive's manifest.json.
+type ArchiveGroupManifest struct {
+	Date       string  `json:"date"`
+	DesignName string  `json:"design_name"`
+	Amp        string  `json:"amp"`
+	SourceRows int     `json:"source_rows"`
+	Points     int     `json:"points"`
+	MinGPSTime float64 `json:"min_gps_time"`
+	MaxGPSTime float64 `json:"max_gps_time"`
+	MinX       float64 `json:"min_x"`
+	MinY       float64 `json:"min_y"`
+	MinZ       float64 `json:"min_z"`
+	MaxX       float64 `json:"max_x"`
+	MaxY       float64 `json:"max_y"`
+	MaxZ       float64 `json:"max_z"`
+	SHA256     string  `json:"sha256"`
+}
+
+// ArchiveManifest is the top-level manifest.json a bundled archive
+// carries, listing every group it contains.
+type ArchiveManifest struct {
+	Groups []ArchiveGroupManifest `json:"groups"`
+}
+
+// BundleDirectory is BundleDirectoryFS using the real filesystem.
+func BundleDirectory(sortedDir, lasDir string, w io.Writer) (ArchiveManifest, error) {
+	return BundleDirectoryFS(fsutil.OS, sortedDir, lasDir, w)
+}
+
+// BundleDirectoryFS tars sortedDir's CSVs (under "sorted/") and lasDir's
+// LAS files (under "las/") into w as one deterministic archive, preceded
+// by a "manifest.json" member describing each group. sortedDir and
+// lasDir are expected to hold the matching outputs of a single
+// sorter.SortCSVDirectory(WithOptions)/ConvertDirectory(FS) run - i.e.
+// one CSV and one LAS file per group, sharing the same base filename.
+//
+// The archive uses tar.FormatPAX (the default format truncates names
+// over 100 bytes - see TestEdgeCases's long "Design/B*456"-style
+// DesignName) and sets every member's ModTime from its group's first CSV
+// row, so bundling the same sorted/LAS output twice produces a
+// byte-for-byte identical archive. w is a plain io.Writer rather than a
+// file path, so callers can stream the archive straight to an S3/GCS
+// upload instead of staging it in a temp file.
+func BundleDirectoryFS(fsys fsutil.Filesystem, sortedDir, lasDir string, w io.Writer) (ArchiveManifest, error) {
+	lasFiles, err := fsys.Glob(filepath.Join(lasDir, "*.las"))
+	if err != nil {
+		return ArchiveManifest{}, fmt.Errorf("error scanning LAS directory: %v", err)
+	}
+	sort.Strings(lasFiles)
+
+	type groupBundle struct {
+		csvName, lasName   string
+		csvBytes, lasBytes []byte
+		modTime            time.Time
+		manifest           ArchiveGroupManifest
+	}
+	bundles := make([]groupBundle, 0, len(lasFiles))
+
+	for _, lasPath := range lasFiles {
+		base := filepath.Base(lasPath)
+		m := groupFilenamePattern.FindStringSubmatch(base)
+		if m == nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: filename doesn't match the expected {date}design{design}amp{amp}.las layout", base)
+		}
+		date, design, amp := m[1], m[2], m[3]
+
+		csvName := base[:len(base)-len(".las")] + ".csv"
+		csvPath := filepath.Join(sortedDir, csvName)
+
+		csvBytes, err := readAll(fsys, csvPath)
+		if err != nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: %v", csvName, err)
+		}
+		sourceRows, modTime, err := countRowsAndFirstTime(csvBytes)
+		if err != nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: %v", csvName, err)
+		}
+
+		lasBytes, err := readAll(fsys, lasPath)
+		if err != nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: %v", base, err)
+		}
+		sum := sha256.Sum256(lasBytes)
+
+		reader, err := las.NewReaderFS(fsys, lasPath)
+		if err != nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: %v", base, err)
+		}
+		header := reader.GetHeader()
+		points, err := reader.ReadPoints()
+		reader.Close()
+		if err != nil {
+			return ArchiveManifest{}, fmt.Errorf("%s: %v", base, err)
+		}
+		minGPS, maxGPS := gpsTimeRange(points)
+
+		bundles = append(bundles, groupBundle{
+			csvName:  csvName,
+			lasName:  base,
+			csvBytes: csvBytes,
+			lasBytes: lasBytes,
+			modTime:  modTime,
+			manifest: ArchiveGroupManifest{
+				Date: date, DesignName: design, Amp: amp,
+				SourceRows: sourceRows,
+				Points:     int(header.PointCount),
+				MinGPSTime: minGPS, MaxGPSTime: maxGPS,
+				MinX: header.MinX, MinY: header.MinY, MinZ: header.MinZ,
+				MaxX: header.MaxX, MaxY: header.MaxY, MaxZ: header.MaxZ,
+				SHA256: hex.EncodeToString(sum[:]),
+			},
+		})
+	}
+
+	manifest := ArchiveManifest{Groups: make([]ArchiveGroupManifest, len(bundles))}
+	var archiveModTime time.Time
+	for i, b := range bundles {
+		manifest.Groups[i] = b.manifest
+		if archiveModTime.IsZero() || (!b.modTime.IsZero() && b.modTime.Before(archiveModTime)) {
+			archiveModTime = b.modTime
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return ArchiveManifest{}, fmt.Errorf("error encoding manifest: %v", err)
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeTarEntry(tw, "manifest.json", manifestJSON, archiveModTime); err != nil {
+		return ArchiveManifest{}, err
+	}
+	for _, b := range bundles {
+		if err := writeTarEntry(tw, "sorted/"+b.csvName, b.csvBytes, b.modTime); err != nil {
+			return ArchiveManifest{}, err
+		}
+	}
+	for _, b := range bundles {
+		if err := writeTarEntry(tw, "las/"+b.lasName, b.lasBytes, b.modTime); err != nil {
+			return ArchiveManifest{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return ArchiveManifest{}, fmt.Errorf("error closing archive: %v", err)
+	}
+
+	return manifest, nil
+}
+
+// writeTarEntry writes one PAX-format tar member named name with the
+// given contents and ModTime.
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+		Format:  tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("error writing tar header for %s: %v", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("error writing tar data for %s: %v", name, err)
+	}
+	return nil
+}
+
+// readAll reads path's full contents through fsys.
+func readAll(fsys fsutil.Filesystem, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// countRowsAndFirstTime counts csvBytes' data rows (excluding the
+// header) and parses the first row's Time column, giving a group's
+// bundled entries a ModTime derived only from their own content.
+func countRowsAndFirstTime(csvBytes []byte) (int, time.Time, error) {
+	reader := csv.NewReader(bytes.NewReader(csvBytes))
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("error reading header: %v", err)
+	}
+	timeIdx := -1
+	for i, col := range header {
+		if col == "Time" {
+			timeIdx = i
+			break
+		}
+	}
+
+	var firstRowTime time.Time
+	rows := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("error reading row %d: %v", rows+2, err)
+		}
+		if rows == 0 && timeIdx >= 0 && timeIdx < len(row) {
+			firstRowTime, err = parseRowTimestamp(row[timeIdx])
+			if err != nil {
+				return 0, time.Time{}, fmt.Errorf("error parsing Time from first row: %v", err)
+			}
+		}
+		rows++
+	}
+	return rows, firstRowTime, nil
+}
+
+// parseRowTimestamp parses a CAT-roller Time cell, accepting the
+// millisecond-precision format written by real exports and the bare
+// seconds format accepted by parseTimeToGPS.
+func parseRowTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse("2006/Jan/02 15:04:05.000", s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006/Jan/02 15:04:05", s)
+}
+
+// gpsTimeRange returns the min/max GPSTime across points. LAS's header
+// has no GPS-time-range field of its own, so this is the only way to
+// recover it for the manifest.
+func gpsTimeRange(points []las.Point) (float64, float64) {
+	if len(points) == 0 {
+		return 0, 0
+	}
+	min, max := points[0].GPSTime, points[0].GPSTime
+	for _, p := range points[1:] {
+		if p.GPSTime < min {
+			min = p.GPSTime
+		}
+		if p.GPSTime > max {
+			max = p.GPSTime
+		}
+	}
+	return min, max
+}
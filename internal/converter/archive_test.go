@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"compactmapper/internal/fsutil"
+)
+
+// TestBundleDirectoryFS verifies BundleDirectoryFS packages a converted
+// group's CSV and LAS output into one archive with a manifest entry that
+// matches them.
+func TestBundleDirectoryFS(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("sorted/2025-10-01designDesign1amp097.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+2025/Oct/01 09:31:02.800,100.6,200.4,5.3,3,4
+`))
+
+	if err := ConvertCSVToLASFS(fsys, "sorted/2025-10-01designDesign1amp097.csv", "las", false, nil, nil, nil); err != nil {
+		t.Fatalf("ConvertCSVToLASFS failed: %v", err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := BundleDirectoryFS(fsys, "sorted", "las", &archive)
+	if err != nil {
+		t.Fatalf("BundleDirectoryFS failed: %v", err)
+	}
+
+	if len(manifest.Groups) != 1 {
+		t.Fatalf("manifest has %d groups, want 1", len(manifest.Groups))
+	}
+	g := manifest.Groups[0]
+	if g.Date != "2025-10-01" || g.DesignName != "Design1" || g.Amp != "097" {
+		t.Errorf("group = %+v, want Date/DesignName/Amp 2025-10-01/Design1/097", g)
+	}
+	if g.SourceRows != 2 {
+		t.Errorf("SourceRows = %d, want 2", g.SourceRows)
+	}
+	if g.Points != 2 {
+		t.Errorf("Points = %d, want 2", g.Points)
+	}
+	if g.SHA256 == "" {
+		t.Error("expected a non-empty SHA256")
+	}
+
+	tr := tar.NewReader(bytes.NewReader(archive.Bytes()))
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("error reading first tar entry: %v", err)
+	}
+	if hdr.Name != "manifest.json" {
+		t.Errorf("first tar entry = %s, want manifest.json", hdr.Name)
+	}
+	if hdr.Format != tar.FormatPAX {
+		t.Errorf("manifest.json format = %v, want FormatPAX", hdr.Format)
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatalf("error reading manifest.json: %v", err)
+	}
+	var decoded ArchiveManifest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("manifest.json does not parse: %v", err)
+	}
+}
+
+// TestBundleDirectoryFSMismatchedFilename verifies a LAS file whose name
+// doesn't match the {date}design{design}amp{amp}.las layout is reported
+// as an error rather than silently producing an incomplete manifest.
+func TestBundleDirectoryFSMismatchedFilename(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("las/not-a-group-name.las", []byte("not a real LAS file"))
+
+	var archive bytes.Buffer
+	if _, err := BundleDirectoryFS(fsys, "sorted", "las", &archive); err == nil {
+		t.Fatal("expected an error for a LAS filename that doesn't match the {date}design{design}amp{amp}.las layout")
+	}
+}
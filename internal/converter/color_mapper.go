@@ -0,0 +1,169 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ColorSample holds everything a ColorMapper might need to pick a color
+// for one CSV row. Fields carries the raw row keyed by header name so a
+// FieldBasedGradient can pull out a column (e.g. CMV, MDP) that isn't
+// otherwise threaded through the parser.
+type ColorSample struct {
+	PassCount     int
+	TargPassCount int
+	Elevation     float64
+	Fields        map[string]string
+}
+
+// ColorMapper assigns an RGB color to a point based on its CSV row. The
+// zero value of most implementations is usable; see each type's doc
+// comment for defaults.
+type ColorMapper interface {
+	Color(s ColorSample) (r, g, b uint16)
+}
+
+// PassCountClassifier is the original three-state classifier: red when
+// under target, green at target, blue over target. It's the default
+// mapper when none is configured.
+type PassCountClassifier struct{}
+
+func (PassCountClassifier) Color(s ColorSample) (uint16, uint16, uint16) {
+	return determineColor(s.PassCount, s.TargPassCount)
+}
+
+// RatioGradient colors a point along a continuous blue -> green -> red
+// ramp based on PassCount/TargPassCount, so a point just one pass short
+// of target looks visually different from one far short, rather than
+// both rendering as flat red.
+type RatioGradient struct{}
+
+func (RatioGradient) Color(s ColorSample) (uint16, uint16, uint16) {
+	if s.TargPassCount <= 0 {
+		return lerpRamp(0)
+	}
+	ratio := float64(s.PassCount) / float64(s.TargPassCount)
+	return lerpRamp(ratio)
+}
+
+// ElevationGradient colors a point by where its elevation falls between
+// Min and Max, again along the blue -> green -> red ramp. Min/Max must
+// be supplied by the caller since the converter streams rows and never
+// sees the full elevation range in advance.
+type ElevationGradient struct {
+	Min, Max float64
+}
+
+func (g ElevationGradient) Color(s ColorSample) (uint16, uint16, uint16) {
+	return lerpRamp(normalize(s.Elevation, g.Min, g.Max))
+}
+
+// FieldBasedGradient colors a point by an arbitrary numeric CSV column
+// (e.g. "CMV" or "MDP" on rollers that report them), scaled between Min
+// and Max. Rows missing the field, or where it doesn't parse as a
+// number, fall back to the ramp's low end rather than erroring - a
+// missing sensor field shouldn't abort the whole conversion.
+type FieldBasedGradient struct {
+	Field    string
+	Min, Max float64
+}
+
+func (g FieldBasedGradient) Color(s ColorSample) (uint16, uint16, uint16) {
+	raw, ok := s.Fields[g.Field]
+	if !ok {
+		return lerpRamp(0)
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return lerpRamp(0)
+	}
+	return lerpRamp(normalize(v, g.Min, g.Max))
+}
+
+// normalize clamps v to [0, 1] relative to [min, max].
+func normalize(v, min, max float64) float64 {
+	if max <= min {
+		return 0
+	}
+	t := (v - min) / (max - min)
+	if t < 0 {
+		return 0
+	}
+	if t > 1 {
+		return 1
+	}
+	return t
+}
+
+// lerpRamp maps t in [0, 1] across a blue -> green -> red ramp. This is
+// a hand-rolled stand-in for a perceptual colormap like viridis/turbo -
+// we don't have an image/color library vendored to generate one from a
+// LUT, so a simple three-stop linear ramp is what's available.
+func lerpRamp(t float64) (uint16, uint16, uint16) {
+	if t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+
+	const full = 65535
+	if t < 0.5 {
+		// blue -> green
+		u := t / 0.5
+		return 0, uint16(full * u), uint16(full * (1 - u))
+	}
+	// green -> red
+	u := (t - 0.5) / 0.5
+	return uint16(full * u), uint16(full * (1 - u)), 0
+}
+
+// Palette is the on-disk representation of a user-supplied color scheme,
+// loaded via LoadPalette so surveyors can match the ramp their existing
+// GIS tools use instead of our built-in ramp.
+type Palette struct {
+	Strategy string   `json:"strategy"`
+	Field    string   `json:"field,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// LoadPalette reads a JSON palette file and builds the ColorMapper it
+// describes. Strategy selects the built-in implementation: "pass_count"
+// (default), "ratio", "elevation", or "field" (Field required). A Min or
+// Max left out of the file is taken as 0.
+//
+// TOML palettes aren't supported: this repo has no vendored TOML parser
+// to draw on, so only the JSON form is implemented for now.
+func LoadPalette(data []byte) (ColorMapper, error) {
+	var p Palette
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("error parsing palette: %v", err)
+	}
+
+	switch p.Strategy {
+	case "", "pass_count":
+		return PassCountClassifier{}, nil
+	case "ratio":
+		return RatioGradient{}, nil
+	case "elevation":
+		min, max := floatOrZero(p.Min), floatOrZero(p.Max)
+		return ElevationGradient{Min: min, Max: max}, nil
+	case "field":
+		if p.Field == "" {
+			return nil, fmt.Errorf("palette strategy %q requires a field name", p.Strategy)
+		}
+		min, max := floatOrZero(p.Min), floatOrZero(p.Max)
+		return FieldBasedGradient{Field: p.Field, Min: min, Max: max}, nil
+	default:
+		return nil, fmt.Errorf("unknown palette strategy: %q", p.Strategy)
+	}
+}
+
+func floatOrZero(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
@@ -0,0 +1,85 @@
+package converter
+
+import "testing"
+
+func TestPassCountClassifierMatchesDetermineColor(t *testing.T) {
+	r, g, b := PassCountClassifier{}.Color(ColorSample{PassCount: 1, TargPassCount: 4})
+	wantR, wantG, wantB := determineColor(1, 4)
+	if r != wantR || g != wantG || b != wantB {
+		t.Errorf("PassCountClassifier.Color = (%d,%d,%d), want (%d,%d,%d)", r, g, b, wantR, wantG, wantB)
+	}
+}
+
+func TestRatioGradientEndpoints(t *testing.T) {
+	r, g, b := RatioGradient{}.Color(ColorSample{PassCount: 0, TargPassCount: 4})
+	if r != 0 || g != 0 || b != 65535 {
+		t.Errorf("ratio 0 = (%d,%d,%d), want pure blue", r, g, b)
+	}
+
+	r, g, b = RatioGradient{}.Color(ColorSample{PassCount: 4, TargPassCount: 4})
+	if r != 65535 || g != 0 || b != 0 {
+		t.Errorf("ratio 1 = (%d,%d,%d), want pure red", r, g, b)
+	}
+}
+
+func TestElevationGradientClampsOutOfRange(t *testing.T) {
+	g := ElevationGradient{Min: 0, Max: 10}
+	rLow, gLow, bLow := g.Color(ColorSample{Elevation: -5})
+	rHigh, gHigh, bHigh := g.Color(ColorSample{Elevation: 100})
+
+	if rLow != 0 || gLow != 0 || bLow != 65535 {
+		t.Errorf("below-range elevation = (%d,%d,%d), want pure blue", rLow, gLow, bLow)
+	}
+	if rHigh != 65535 || gHigh != 0 || bHigh != 0 {
+		t.Errorf("above-range elevation = (%d,%d,%d), want pure red", rHigh, gHigh, bHigh)
+	}
+}
+
+func TestFieldBasedGradientMissingField(t *testing.T) {
+	g := FieldBasedGradient{Field: "CMV", Min: 0, Max: 10}
+	r, gr, b := g.Color(ColorSample{Fields: map[string]string{}})
+	if r != 0 || gr != 0 || b != 65535 {
+		t.Errorf("missing field = (%d,%d,%d), want pure blue fallback", r, gr, b)
+	}
+
+	r, gr, b = g.Color(ColorSample{Fields: map[string]string{"CMV": "5"}})
+	if r != 0 || gr != 65535 || b != 0 {
+		t.Errorf("CMV=5 of [0,10] = (%d,%d,%d), want pure green midpoint", r, gr, b)
+	}
+}
+
+func TestLoadPaletteStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    ColorMapper
+		wantErr bool
+	}{
+		{"default", `{}`, PassCountClassifier{}, false},
+		{"pass_count", `{"strategy":"pass_count"}`, PassCountClassifier{}, false},
+		{"ratio", `{"strategy":"ratio"}`, RatioGradient{}, false},
+		{"elevation", `{"strategy":"elevation","min":0,"max":10}`, ElevationGradient{Min: 0, Max: 10}, false},
+		{"field", `{"strategy":"field","field":"CMV","min":0,"max":10}`, FieldBasedGradient{Field: "CMV", Min: 0, Max: 10}, false},
+		{"field missing name", `{"strategy":"field"}`, nil, true},
+		{"unknown", `{"strategy":"nonsense"}`, nil, true},
+		{"invalid json", `not json`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadPalette([]byte(tt.json))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPalette failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadPalette(%s) = %#v, want %#v", tt.json, got, tt.want)
+			}
+		})
+	}
+}
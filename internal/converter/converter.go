@@ -1,13 +1,19 @@
 package converter
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
+	"compactmapper/internal/fsutil"
 	"compactmapper/las"
 )
 
@@ -42,29 +48,104 @@ func parseTimeToGPS(timeStr string) (float64, error) {
 	return float64(t.Unix()) + float64(t.Nanosecond())/1e9, nil
 }
 
-// ConvertCSVToLAS converts a single CSV file to LAS format
+// BBox bounds CellE_m/CellN_m so rows from sensor glitches - a roller
+// momentarily reporting coordinates far off the jobsite - get rejected
+// instead of poisoning the point cloud. A nil *BBox disables the check.
+type BBox struct {
+	MinE, MaxE float64
+	MinN, MaxN float64
+}
+
+// contains reports whether (e, n) falls within the box.
+func (b *BBox) contains(e, n float64) bool {
+	if b == nil {
+		return true
+	}
+	return e >= b.MinE && e <= b.MaxE && n >= b.MinN && n <= b.MaxN
+}
+
+// ConvertCSVToLAS converts a single CSV file to LAS format using the real
+// filesystem, aborting on the first malformed row and coloring points
+// with the default PassCountClassifier.
 func ConvertCSVToLAS(csvPath, outputDir string) error {
+	return ConvertCSVToLASFS(fsutil.OS, csvPath, outputDir, false, nil, nil, nil)
+}
+
+// ConvertCSVToLASFS streams a single CSV file into an LAS file, performing
+// all file I/O through fsys. Rows are read and written one at a time
+// rather than buffered in full, so a multi-hundred-MB day-file doesn't
+// need to fit in memory.
+//
+// skipErrors mirrors sorter.SortCSV: when true, a row with a parse error
+// or a coordinate/elevation outside sane limits is logged to errorLog
+// (row number, column name, raw value, and the parse error) and skipped
+// instead of aborting the whole file. bounds, if non-nil, rejects rows
+// whose CellE_m/CellN_m fall outside it; Elevation_m is always rejected
+// when it's NaN or ±Inf, since that's a telltale CAT roller sensor
+// glitch rather than valid data. colorMapper selects how points are
+// colored; a nil colorMapper defaults to PassCountClassifier.
+func ConvertCSVToLASFS(fsys fsutil.Filesystem, csvPath, outputDir string, skipErrors bool, errorLog *os.File, bounds *BBox, colorMapper ColorMapper) error {
+	_, err := convertCSVToLASCounted(fsys, csvPath, outputDir, skipErrors, errorLog, bounds, colorMapper, LASFormat{}, las.CRS{}, 0, false, las.CompressionNone)
+	return err
+}
+
+// LASFormat selects the LAS version and point data record format that
+// ConvertDirectory(FS) writes. The zero value keeps the long-standing
+// default of LAS 1.2, point format 3; set Version to {1, 4} and
+// PointFormat to 6 or 7 to target modern tooling instead (see
+// las.Writer.SetFormat for the supported combinations).
+type LASFormat struct {
+	Version     las.Version
+	PointFormat uint8
+}
+
+// isZero reports whether f is the zero value, meaning "use the writer's
+// built-in default" rather than an explicit format choice.
+func (f LASFormat) isZero() bool {
+	return f == LASFormat{}
+}
+
+// convertCSVToLASCounted does the work of ConvertCSVToLASFS and
+// additionally reports how many points were written, for callers (e.g.
+// ConvertDirectory) that need per-file stats. When sourceEPSG is non-zero
+// and differs from crs.EPSG, a Reprojector is started for the duration of
+// this one file and used to transform every point's X/Y/Z before it's
+// colored and written - see ConvertOptions.SourceEPSG. extraBytes mirrors
+// ConvertOptions.ExtraBytes: see that field's doc comment. compress
+// mirrors ConvertOptions.Compress: CompressionLAZ writes a .laz file via
+// las.Writer.WriteLAZ instead of a .las file. Points are streamed
+// straight to disk via las.StreamWriter when the requested output needs
+// none of lasFormat/crs/extraBytes/compress (see ensureSink), so a
+// plain-format conversion doesn't need to buffer the whole file in
+// memory; anything else falls back to the buffered las.Writer.
+func convertCSVToLASCounted(fsys fsutil.Filesystem, csvPath, outputDir string, skipErrors bool, errorLog *os.File, bounds *BBox, colorMapper ColorMapper, lasFormat LASFormat, crs las.CRS, sourceEPSG int, extraBytes bool, compress las.Compression) (int, error) {
+	if colorMapper == nil {
+		colorMapper = PassCountClassifier{}
+	}
+
+	var reprojector *Reprojector
+	if sourceEPSG != 0 && crs.EPSG != 0 && sourceEPSG != crs.EPSG {
+		r, err := NewReprojector(sourceEPSG, crs.EPSG)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+		reprojector = r
+	}
 	// Open CSV file
-	file, err := os.Open(csvPath)
+	file, err := fsys.Open(csvPath)
 	if err != nil {
-		return fmt.Errorf("error opening CSV file: %v", err)
+		return 0, fmt.Errorf("error opening CSV file: %v", err)
 	}
 	defer func() { _ = file.Close() }() // Read-only file; close error is non-actionable
 
 	reader := csv.NewReader(file)
 
-	// Read all records
-	records, err := reader.ReadAll()
+	header, err := reader.Read()
 	if err != nil {
-		return fmt.Errorf("error reading CSV: %v", err)
-	}
-
-	if len(records) < 2 {
-		return fmt.Errorf("CSV file is empty or has no data rows")
+		return 0, fmt.Errorf("CSV file is empty or has no data rows")
 	}
 
-	// Parse header
-	header := records[0]
 	colMap := make(map[string]int)
 	for i, col := range header {
 		colMap[col] = i
@@ -74,103 +155,508 @@ func ConvertCSVToLAS(csvPath, outputDir string) error {
 	required := []string{"Time", "CellE_m", "CellN_m", "Elevation_m", "PassCount", "TargPassCount"}
 	for _, col := range required {
 		if _, ok := colMap[col]; !ok {
-			return fmt.Errorf("missing required column: %s", col)
+			return 0, fmt.Errorf("missing required column: %s", col)
 		}
 	}
 
 	// Create output directory if needed
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("error creating output directory: %v", err)
+	if err := fsys.MkdirAll(outputDir, 0755); err != nil {
+		return 0, fmt.Errorf("error creating output directory: %v", err)
 	}
 
-	// Create LAS writer
-	writer := las.NewWriter()
+	baseName := filepath.Base(csvPath)
 
-	// Parse points
-	for i := 1; i < len(records); i++ {
-		row := records[i]
+	// Generate output filename
+	ext := ".las"
+	if compress == las.CompressionLAZ {
+		ext = ".laz"
+	}
+	lasName := baseName[:len(baseName)-4] + ext
+	outputPath := filepath.Join(outputDir, lasName)
 
-		// Parse time
-		gpsTime, err := parseTimeToGPS(row[colMap["Time"]])
-		if err != nil {
-			return fmt.Errorf("row %d: invalid Time value: %v", i+1, err)
+	var sink pointSink
+	var streamFile io.WriteCloser
+	var streamWriter *las.StreamWriter
+	var writer *las.Writer
+
+	// ensureSink lazily picks a pointSink on the first point actually
+	// written, so a CSV with zero convertible rows never creates an
+	// output file at all (matching the pre-streaming behavior of only
+	// calling writer.Write once at least one point has been buffered).
+	//
+	// It prefers streaming every point straight to disk via
+	// las.StreamWriter, which needs O(1) memory instead of buffering the
+	// whole file the way las.Writer does - but StreamWriter only covers
+	// its one supported combination (LAS 1.2, point format 3, no CRS, no
+	// extra bytes, no LAZ) and needs an io.WriteSeeker, so any request
+	// for something else, or a Filesystem whose Create doesn't return a
+	// seekable writer, falls back to the buffered las.Writer path.
+	ensureSink := func() error {
+		if sink != nil {
+			return nil
+		}
+		if lasFormat.isZero() && crs == (las.CRS{}) && !extraBytes && compress == las.CompressionNone {
+			if f, err := fsys.Create(outputPath); err == nil {
+				if seeker, ok := f.(io.WriteSeeker); ok {
+					if sw, err := las.NewStreamWriter(seeker); err == nil {
+						streamFile, streamWriter = f, sw
+						sink = streamSink{sw}
+						return nil
+					}
+				}
+				_ = f.Close()
+			}
 		}
 
-		x, err := strconv.ParseFloat(row[colMap["CellE_m"]], 64)
-		if err != nil {
-			return fmt.Errorf("row %d: invalid CellE_m value: %v", i+1, err)
+		writer = las.NewWriterFS(fsys)
+		if !lasFormat.isZero() {
+			if err := writer.SetFormat(lasFormat.Version, lasFormat.PointFormat); err != nil {
+				return err
+			}
 		}
+		if crs != (las.CRS{}) {
+			writer.SetCRS(crs)
+		}
+		writer.SetExtraBytes(extraBytes)
+		writer.SetCompression(compress)
+		sink = bufferedSink{writer}
+		return nil
+	}
+
+	written := 0
+	skipped := 0
+	malformedRows := 0
 
-		y, err := strconv.ParseFloat(row[colMap["CellN_m"]], 64)
+	for rowNum := 2; ; rowNum++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			return fmt.Errorf("row %d: invalid CellN_m value: %v", i+1, err)
+			malformedRows++
+			if skipErrors {
+				skipped++
+				logConvertSkip(errorLog, baseName, rowNum, "", "", err)
+				continue
+			}
+			return 0, fmt.Errorf("row %d: error reading row: %v", rowNum, err)
 		}
 
-		z, err := strconv.ParseFloat(row[colMap["Elevation_m"]], 64)
-		if err != nil {
-			return fmt.Errorf("row %d: invalid Elevation_m value: %v", i+1, err)
+		pt, skipErr := parseCSVPoint(row, colMap, bounds, colorMapper, extraBytes)
+		if skipErr != nil {
+			if skipErrors {
+				skipped++
+				logConvertSkip(errorLog, baseName, rowNum, skipErr.column, skipErr.raw, skipErr.err)
+				continue
+			}
+			return 0, fmt.Errorf("row %d: %s", rowNum, skipErr.err)
 		}
 
-		passCount, err := strconv.Atoi(row[colMap["PassCount"]])
-		if err != nil {
-			return fmt.Errorf("row %d: invalid PassCount value: %v", i+1, err)
+		if reprojector != nil {
+			x, y, z, err := reprojector.Transform(pt.X, pt.Y, pt.Z)
+			if err != nil {
+				if skipErrors {
+					skipped++
+					logConvertSkip(errorLog, baseName, rowNum, "CellE_m/CellN_m", fmt.Sprintf("(%f, %f)", pt.X, pt.Y), fmt.Errorf("reprojection failed: %v", err))
+					continue
+				}
+				return 0, fmt.Errorf("row %d: reprojection failed: %v", rowNum, err)
+			}
+			pt.X, pt.Y, pt.Z = x, y, z
 		}
 
-		targPass, err := strconv.Atoi(row[colMap["TargPassCount"]])
-		if err != nil {
-			return fmt.Errorf("row %d: invalid TargPassCount value: %v", i+1, err)
+		if err := ensureSink(); err != nil {
+			return 0, err
 		}
+		if err := sink.AddPoint(pt); err != nil {
+			if streamFile != nil {
+				_ = streamFile.Close()
+			}
+			return 0, fmt.Errorf("row %d: error writing point: %v", rowNum, err)
+		}
+		written++
+	}
 
-		// Determine color based on pass count
-		r, g, b := determineColor(passCount, targPass)
+	if skipErrors && skipped > 0 && errorLog != nil {
+		fmt.Fprintf(errorLog, "File: %s - Total skipped rows during conversion: %d\n", baseName, skipped)
+	}
 
-		// Add point to writer
-		writer.AddPoint(las.Point{
-			X:              x,
-			Y:              y,
-			Z:              z,
-			R:              r,
-			G:              g,
-			B:              b,
-			Intensity:      0,
-			Classification: 1,
-			GPSTime:        gpsTime,
-		})
+	if sink == nil {
+		// With skipErrors, a file whose rows were all rejected by
+		// content validation (bad values, out-of-bounds coordinates,
+		// failed reprojection) filtered out exactly what it was asked
+		// to - that's a valid (if unlucky) empty result, not a
+		// failure. A file that contains a malformed CSV row (one
+		// reader.Read() itself couldn't parse), though, is itself
+		// broken - even under skipErrors that should still surface as
+		// an error so batch callers like ConvertDirectory can report
+		// it in that file's FileResult instead of silent success.
+		if skipErrors && malformedRows == 0 {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("no points to write")
 	}
 
-	// Generate output filename
-	baseName := filepath.Base(csvPath)
-	lasName := baseName[:len(baseName)-4] + ".las"
-	outputPath := filepath.Join(outputDir, lasName)
+	if streamWriter != nil {
+		if err := streamWriter.Close(); err != nil {
+			_ = streamFile.Close()
+			return 0, fmt.Errorf("error writing LAS file: %v", err)
+		}
+		if err := streamFile.Close(); err != nil {
+			return 0, fmt.Errorf("error closing LAS file: %v", err)
+		}
+		return written, nil
+	}
 
 	// Write LAS file
 	if err := writer.Write(outputPath); err != nil {
-		return fmt.Errorf("error writing LAS file: %v", err)
+		return 0, fmt.Errorf("error writing LAS file: %v", err)
 	}
 
+	return written, nil
+}
+
+// pointSink is the common interface over las.Writer (buffers every
+// point, then encodes the whole file on Write) and las.StreamWriter
+// (encodes and writes each point immediately): convertCSVToLASCounted's
+// row loop only needs AddPoint, so it doesn't need to know which one
+// it's driving.
+type pointSink interface {
+	AddPoint(p las.Point) error
+}
+
+// bufferedSink adapts las.Writer's AddPoint (which can't fail - it just
+// appends to an in-memory slice) to pointSink.
+type bufferedSink struct{ w *las.Writer }
+
+func (s bufferedSink) AddPoint(p las.Point) error {
+	s.w.AddPoint(p)
 	return nil
 }
 
-// ConvertDirectory converts all CSV files in a directory to LAS format
-func ConvertDirectory(inputDir, outputDir string) (int, error) {
-	// Find all CSV files
-	files, err := filepath.Glob(filepath.Join(inputDir, "*.csv"))
+// streamSink adapts las.StreamWriter, whose AddPoint already returns an
+// error (each point is written to disk immediately, so a write can
+// fail), straight through to pointSink.
+type streamSink struct{ w *las.StreamWriter }
+
+func (s streamSink) AddPoint(p las.Point) error { return s.w.AddPoint(p) }
+
+// rowSkipError describes why a single CSV row was rejected, carrying
+// enough detail (column name, raw value) for logConvertSkip to write a
+// useful line to the error log.
+type rowSkipError struct {
+	column string
+	raw    string
+	err    error
+}
+
+func (e *rowSkipError) Error() string { return e.err.Error() }
+
+// parseCSVPoint parses one CSV row into a las.Point, or returns a
+// rowSkipError describing why the row is unusable (parse failure, a
+// NaN/Inf elevation, or coordinates outside bounds). extraBytes mirrors
+// ConvertOptions.ExtraBytes: when true, the point's Extra is populated
+// from the row's compaction telemetry columns.
+func parseCSVPoint(row []string, colMap map[string]int, bounds *BBox, colorMapper ColorMapper, extraBytes bool) (las.Point, *rowSkipError) {
+	timeStr := row[colMap["Time"]]
+	gpsTime, err := parseTimeToGPS(timeStr)
 	if err != nil {
-		return 0, fmt.Errorf("error scanning directory: %v", err)
+		return las.Point{}, &rowSkipError{"Time", timeStr, fmt.Errorf("invalid Time value: %v", err)}
+	}
+
+	eRaw := row[colMap["CellE_m"]]
+	x, err := strconv.ParseFloat(eRaw, 64)
+	if err != nil {
+		return las.Point{}, &rowSkipError{"CellE_m", eRaw, fmt.Errorf("invalid CellE_m value: %v", err)}
+	}
+
+	nRaw := row[colMap["CellN_m"]]
+	y, err := strconv.ParseFloat(nRaw, 64)
+	if err != nil {
+		return las.Point{}, &rowSkipError{"CellN_m", nRaw, fmt.Errorf("invalid CellN_m value: %v", err)}
+	}
+
+	zRaw := row[colMap["Elevation_m"]]
+	z, err := strconv.ParseFloat(zRaw, 64)
+	if err != nil {
+		return las.Point{}, &rowSkipError{"Elevation_m", zRaw, fmt.Errorf("invalid Elevation_m value: %v", err)}
+	}
+	if math.IsNaN(z) || math.IsInf(z, 0) {
+		return las.Point{}, &rowSkipError{"Elevation_m", zRaw, fmt.Errorf("elevation is NaN/Inf (sensor glitch)")}
+	}
+
+	if !bounds.contains(x, y) {
+		return las.Point{}, &rowSkipError{
+			"CellE_m/CellN_m",
+			fmt.Sprintf("(%s, %s)", eRaw, nRaw),
+			fmt.Errorf("coordinates outside configured bounds (sensor glitch)"),
+		}
+	}
+
+	passRaw := row[colMap["PassCount"]]
+	passCount, err := strconv.Atoi(passRaw)
+	if err != nil {
+		return las.Point{}, &rowSkipError{"PassCount", passRaw, fmt.Errorf("invalid PassCount value: %v", err)}
+	}
+
+	targRaw := row[colMap["TargPassCount"]]
+	targPass, err := strconv.Atoi(targRaw)
+	if err != nil {
+		return las.Point{}, &rowSkipError{"TargPassCount", targRaw, fmt.Errorf("invalid TargPassCount value: %v", err)}
+	}
+
+	fields := make(map[string]string, len(colMap))
+	for col, idx := range colMap {
+		if idx < len(row) {
+			fields[col] = row[idx]
+		}
+	}
+
+	r, g, b := colorMapper.Color(ColorSample{
+		PassCount:     passCount,
+		TargPassCount: targPass,
+		Elevation:     z,
+		Fields:        fields,
+	})
+
+	var extra *las.ExtraAttributes
+	if extraBytes {
+		extra = buildExtraAttributes(fields)
+	}
+
+	return las.Point{
+		X:              x,
+		Y:              y,
+		Z:              z,
+		R:              r,
+		G:              g,
+		B:              b,
+		Intensity:      0,
+		Classification: 1,
+		GPSTime:        gpsTime,
+		Extra:          extra,
+	}, nil
+}
+
+// logConvertSkip writes one error-log line for a skipped CSV row. column
+// and raw may be empty when the failure was a row-level read error rather
+// than a single bad field.
+func logConvertSkip(errorLog *os.File, filename string, rowNum int, column, raw string, err error) {
+	if errorLog == nil {
+		return
+	}
+	if column == "" {
+		fmt.Fprintf(errorLog, "File: %s, Row %d: %v\n", filename, rowNum, err)
+		return
+	}
+	fmt.Fprintf(errorLog, "File: %s, Row %d, Column %s, Value %q: %v\n", filename, rowNum, column, raw, err)
+}
+
+// FileResult records the outcome of converting a single CSV file.
+type FileResult struct {
+	Path          string
+	Err           error
+	PointsWritten int
+	Duration      time.Duration
+}
+
+// ConvertOptions configures a ConvertDirectory run.
+type ConvertOptions struct {
+	// Workers is the number of files converted concurrently. Zero or
+	// negative selects runtime.NumCPU().
+	Workers int
+	// SkipErrors allows the batch to continue past a file that fails to
+	// convert; the failure is recorded in that file's FileResult instead
+	// of aborting the remaining work.
+	SkipErrors bool
+	// Progress, if non-nil, receives a FileResult as each file finishes so
+	// callers (e.g. the GUI) can report live per-file progress instead of
+	// waiting for the whole batch.
+	Progress chan<- FileResult
+	// ErrorLog, if non-nil, receives one line per skipped CSV row (see
+	// SkipErrors) across all files in the batch.
+	ErrorLog *os.File
+	// Bounds, if non-nil, rejects rows whose CellE_m/CellN_m fall outside
+	// it; see BBox.
+	Bounds *BBox
+	// ColorMapper selects how points are colored. A nil ColorMapper
+	// defaults to PassCountClassifier.
+	ColorMapper ColorMapper
+	// LASFormat selects the LAS version/point format written for each
+	// file. The zero value keeps the legacy LAS 1.2 point format 3
+	// default; see LASFormat.
+	LASFormat LASFormat
+	// CRS, if non-zero, tags each output LAS file with a coordinate
+	// reference system: a GeoKeyDirectoryTag/OGC WKT VLR pair plus a
+	// sidecar .prj file (see las.CRS, las.Writer.SetCRS).
+	CRS las.CRS
+	// SourceEPSG, if non-zero and different from CRS.EPSG, reprojects
+	// every point from SourceEPSG to CRS.EPSG via an external cs2cs
+	// process before it's written (see Reprojector). Ignored when CRS is
+	// the zero value.
+	SourceEPSG int
+	// ExtraBytes attaches each row's compaction telemetry (LastCMV,
+	// TargCMV, LastMDP, LastEVIB1, LastEVIB2, PassCount, TotalPasses,
+	// LastTemp) to its point as a LAS Extra Bytes VLR (see
+	// las.Writer.SetExtraBytes, las.CompactionExtraByteFields) instead of
+	// leaving it to be lossily packed into RGB by a ColorMapper. It works
+	// with any LASFormat, but is intended to pair with LAS 1.4 point
+	// format 6 or 7, which have the spare per-point capacity formats 2/3
+	// lack.
+	ExtraBytes bool
+	// Compress selects whether each output file is a plain .las file
+	// (CompressionNone, the default) or a LAZ-compressed .laz file
+	// (CompressionLAZ); see las.Writer.WriteLAZ for how compression is
+	// actually performed.
+	Compress las.Compression
+	// Timeout bounds how long a single file's conversion is allowed to
+	// run before it's recorded as a failed FileResult and its worker
+	// moves on to the next file. Zero or negative disables the timeout;
+	// convertCSVToLASCounted has no cancellation points of its own, so a
+	// timed-out file's goroutine keeps running in the background - the
+	// timeout only bounds how long the batch waits on it.
+	Timeout time.Duration
+}
+
+// ConvertDirectory converts all CSV files in inputDir to LAS files in
+// outputDir using a bounded worker pool over the real filesystem. See
+// ConvertDirectoryFS for the Filesystem-parameterized version.
+func ConvertDirectory(ctx context.Context, inputDir, outputDir string, opts ConvertOptions) ([]FileResult, error) {
+	return ConvertDirectoryFS(ctx, fsutil.OS, inputDir, outputDir, opts)
+}
+
+// ConvertDirectoryFS converts all CSV files in inputDir to LAS files in
+// outputDir using a bounded worker pool, performing all file I/O through
+// fsys. It returns one FileResult per input file (order not guaranteed to
+// match directory order) plus an error when the directory itself couldn't
+// be scanned, when no CSV files were found, or - when SkipErrors is false
+// - when the first per-file conversion error is encountered. Conversion
+// stops fanning out new work once ctx is cancelled; in-flight files still
+// run to completion.
+func ConvertDirectoryFS(ctx context.Context, fsys fsutil.Filesystem, inputDir, outputDir string, opts ConvertOptions) ([]FileResult, error) {
+	files, err := fsys.Glob(filepath.Join(inputDir, "*.csv"))
+	if err != nil {
+		return nil, fmt.Errorf("error scanning directory: %v", err)
 	}
 
 	if len(files) == 0 {
-		return 0, fmt.Errorf("no CSV files found in %s", inputDir)
+		return nil, fmt.Errorf("no CSV files found in %s", inputDir)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan string)
+	results := make([]FileResult, len(files))
+
+	var wg sync.WaitGroup
+	var firstErr error
+	var mu sync.Mutex
+
+	worker := func() {
+		defer wg.Done()
+		for csvFile := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			start := time.Now()
+			points, convErr := convertFileBounded(fsys, csvFile, outputDir, opts)
+			result := FileResult{
+				Path:          csvFile,
+				Err:           convErr,
+				PointsWritten: points,
+				Duration:      time.Since(start),
+			}
+
+			if convErr != nil && !opts.SkipErrors {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("error converting %s: %v", filepath.Base(csvFile), convErr)
+				}
+				mu.Unlock()
+			}
+
+			if opts.Progress != nil {
+				opts.Progress <- result
+			}
+
+			mu.Lock()
+			for i, f := range files {
+				if f == csvFile {
+					results[i] = result
+					break
+				}
+			}
+			mu.Unlock()
+		}
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker()
 	}
 
-	// Convert each file
-	successCount := 0
+feed:
 	for _, csvFile := range files {
-		if err := ConvertCSVToLAS(csvFile, outputDir); err != nil {
-			return successCount, fmt.Errorf("error converting %s: %v", filepath.Base(csvFile), err)
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- csvFile:
+		}
+
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop {
+			break
 		}
-		successCount++
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return results, ctxErr
 	}
 
-	return successCount, nil
+	return results, nil
+}
+
+// convertFileBounded runs convertCSVToLASCounted for one file, applying
+// opts.Timeout if set. convertCSVToLASCounted has no cancellation points,
+// so a timed-out run's goroutine is abandoned to finish in the
+// background rather than being stopped.
+func convertFileBounded(fsys fsutil.Filesystem, csvFile, outputDir string, opts ConvertOptions) (int, error) {
+	if opts.Timeout <= 0 {
+		return convertCSVToLASCounted(fsys, csvFile, outputDir, opts.SkipErrors, opts.ErrorLog, opts.Bounds, opts.ColorMapper, opts.LASFormat, opts.CRS, opts.SourceEPSG, opts.ExtraBytes, opts.Compress)
+	}
+
+	type outcome struct {
+		points int
+		err    error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		points, err := convertCSVToLASCounted(fsys, csvFile, outputDir, opts.SkipErrors, opts.ErrorLog, opts.Bounds, opts.ColorMapper, opts.LASFormat, opts.CRS, opts.SourceEPSG, opts.ExtraBytes, opts.Compress)
+		done <- outcome{points: points, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.points, out.err
+	case <-time.After(opts.Timeout):
+		return 0, fmt.Errorf("converting %s timed out after %s", filepath.Base(csvFile), opts.Timeout)
+	}
 }
@@ -0,0 +1,325 @@
+package converter
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// TestConvertCSVToLASFSMemFilesystem exercises the happy path entirely
+// in-memory, with no real disk I/O.
+func TestConvertCSVToLASFSMemFilesystem(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+
+	if err := ConvertCSVToLASFS(fsys, "input/test.csv", "output", false, nil, nil, nil); err != nil {
+		t.Fatalf("ConvertCSVToLASFS failed: %v", err)
+	}
+
+	if _, err := fsys.Stat("output/test.las"); err != nil {
+		t.Errorf("expected output/test.las to exist: %v", err)
+	}
+}
+
+// TestConvertCSVToLASFSStreamsWithoutBuffering runs the default
+// (LASFormat{}, no CRS, no extra bytes, no compression) conversion path
+// against MemFilesystem, whose Create returns a seekable writer, so the
+// points take the las.StreamWriter route, and verifies the result reads
+// back as LAS 1.2 point format 3 with every point. The output alone does
+// not show which writer produced it; the buffered las.Writer would pass
+// the same checks.
+func TestConvertCSVToLASFSStreamsWithoutBuffering(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,4500000.2,500000.1,5.2,2,4
+2025/Oct/01 09:30:03.000,4500000.5,500000.4,5.3,4,4
+`))
+
+	if err := ConvertCSVToLASFS(fsys, "input/test.csv", "output", false, nil, nil, nil); err != nil {
+		t.Fatalf("ConvertCSVToLASFS failed: %v", err)
+	}
+
+	reader, err := las.NewReaderFS(fsys, "output/test.las")
+	if err != nil {
+		t.Fatalf("NewReaderFS failed: %v", err)
+	}
+	defer reader.Close()
+
+	header := reader.GetHeader()
+	if header.PointCount != 2 {
+		t.Errorf("PointCount = %d, want 2", header.PointCount)
+	}
+	if header.VersionMajor != 1 || header.VersionMinor != 2 || header.PointFormat != 3 {
+		t.Errorf("version/format = %d.%d fmt%d, want 1.2 fmt3 (StreamWriter's only supported combination)", header.VersionMajor, header.VersionMinor, header.PointFormat)
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2", len(points))
+	}
+}
+
+// TestConvertCSVToLASFSMalformedInput verifies malformed CSV data still
+// produces a descriptive error against the in-memory filesystem.
+func TestConvertCSVToLASFSMalformedInput(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/bad.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,not-a-number,200.3,5.2,2,4
+`))
+
+	err := ConvertCSVToLASFS(fsys, "input/bad.csv", "output", false, nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for malformed CellN_m value, got nil")
+	}
+}
+
+// TestConvertCSVToLASFSPermissionError verifies an output write failure
+// (e.g. a read-only output directory) surfaces as an error instead of
+// panicking.
+func TestConvertCSVToLASFSPermissionError(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+	fsys.DenyCreate = func(name string) bool { return name == "output/test.las" }
+
+	err := ConvertCSVToLASFS(fsys, "input/test.csv", "output", false, nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected a permission error writing the LAS file, got nil")
+	}
+}
+
+// TestConvertCSVToLASFSSkipErrors verifies that with skipErrors set, a
+// row with an unparseable field is logged and skipped rather than
+// aborting the whole file, and the surviving good row still converts.
+func TestConvertCSVToLASFSSkipErrors(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/mixed.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,not-a-number,200.3,5.2,2,4
+2025/Oct/01 09:30:03.800,100.5,200.3,5.2,2,4
+`))
+
+	errorLog, err := os.CreateTemp(t.TempDir(), "convert-errors-*.log")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	defer errorLog.Close()
+
+	if err := ConvertCSVToLASFS(fsys, "input/mixed.csv", "output", true, errorLog, nil, nil); err != nil {
+		t.Fatalf("ConvertCSVToLASFS failed: %v", err)
+	}
+
+	logData, err := os.ReadFile(errorLog.Name())
+	if err != nil {
+		t.Fatalf("reading error log failed: %v", err)
+	}
+	if !strings.Contains(string(logData), "CellN_m") {
+		t.Errorf("expected error log to mention CellN_m, got: %s", logData)
+	}
+
+	if _, err := fsys.Stat("output/mixed.las"); err != nil {
+		t.Errorf("expected output/mixed.las to exist: %v", err)
+	}
+}
+
+// TestConvertCSVToLASFSBoundsRejection verifies a row whose coordinates
+// fall outside the configured BBox is rejected like any other bad row.
+func TestConvertCSVToLASFSBoundsRejection(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/outlier.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,999999,200.3,5.2,2,4
+`))
+
+	bounds := &BBox{MinE: 0, MaxE: 1000, MinN: 0, MaxN: 1000}
+
+	err := ConvertCSVToLASFS(fsys, "input/outlier.csv", "output", false, nil, bounds, nil)
+	if err == nil {
+		t.Fatal("expected an error for out-of-bounds coordinates, got nil")
+	}
+
+	errorLog, logErr := os.CreateTemp(t.TempDir(), "convert-errors-*.log")
+	if logErr != nil {
+		t.Fatalf("CreateTemp failed: %v", logErr)
+	}
+	defer errorLog.Close()
+
+	if err := ConvertCSVToLASFS(fsys, "input/outlier.csv", "output", true, errorLog, bounds, nil); err != nil {
+		t.Fatalf("ConvertCSVToLASFS with skipErrors failed: %v", err)
+	}
+	logData, _ := os.ReadFile(errorLog.Name())
+	if !strings.Contains(string(logData), "bounds") {
+		t.Errorf("expected error log to mention bounds rejection, got: %s", logData)
+	}
+}
+
+// TestConvertCSVToLASFSElevationNaN verifies a row with a non-finite
+// Elevation_m (a telltale sensor glitch) is rejected even with no bounds
+// configured.
+func TestConvertCSVToLASFSElevationNaN(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/glitch.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,NaN,2,4
+`))
+
+	err := ConvertCSVToLASFS(fsys, "input/glitch.csv", "output", false, nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for NaN elevation, got nil")
+	}
+}
+
+// TestConvertCSVToLASFSLASFormat verifies ConvertOptions.LASFormat
+// reaches the LAS writer, producing a LAS 1.4 point format 7 file
+// instead of the legacy 1.2/format 3 default.
+func TestConvertCSVToLASFSLASFormat(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+
+	results, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{
+		LASFormat: LASFormat{Version: las.Version{Major: 1, Minor: 4}, PointFormat: 7},
+	})
+	if err != nil {
+		t.Fatalf("ConvertDirectoryFS failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	reader, err := las.NewReaderFS(fsys, "output/test.las")
+	if err != nil {
+		t.Fatalf("NewReaderFS failed: %v", err)
+	}
+	defer reader.Close()
+
+	header := reader.GetHeader()
+	if header.VersionMinor != 4 || header.PointFormat != 7 {
+		t.Errorf("header = {VersionMinor:%d PointFormat:%d}, want {4, 7}", header.VersionMinor, header.PointFormat)
+	}
+}
+
+// TestConvertDirectoryFSCRS verifies ConvertOptions.CRS tags the output
+// LAS file with a GeoKeyDirectoryTag VLR and sidecar .prj.
+func TestConvertDirectoryFSCRS(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+
+	results, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{
+		CRS: las.CRS{EPSG: 32610},
+	})
+	if err != nil {
+		t.Fatalf("ConvertDirectoryFS failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	reader, err := las.NewReaderFS(fsys, "output/test.las")
+	if err != nil {
+		t.Fatalf("NewReaderFS failed: %v", err)
+	}
+	defer reader.Close()
+
+	if header := reader.GetHeader(); header.OffsetToPointData <= 227 {
+		t.Errorf("OffsetToPointData = %d, want > 227 (CRS VLRs should push point data back)", header.OffsetToPointData)
+	}
+
+	if _, err := fsys.Stat("output/test.prj"); err != nil {
+		t.Errorf("expected output/test.prj to exist: %v", err)
+	}
+}
+
+// TestConvertDirectoryFSSourceEPSGWithoutCS2CS verifies a SourceEPSG set
+// via ConvertOptions without a working cs2cs surfaces a clear error
+// instead of silently skipping reprojection.
+func TestConvertDirectoryFSSourceEPSGWithoutCS2CS(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+
+	_, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{
+		CRS:        las.CRS{EPSG: 32610},
+		SourceEPSG: 4326,
+	})
+	if err == nil {
+		t.Fatal("expected an error when cs2cs is unavailable, got nil")
+	}
+}
+
+// TestConvertDirectoryFSMemFilesystem exercises the worker-pool directory
+// conversion entirely in-memory.
+func TestConvertDirectoryFSMemFilesystem(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	csvContent := []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,4
+`)
+	fsys.WriteFile("input/a.csv", csvContent)
+	fsys.WriteFile("input/b.csv", csvContent)
+
+	results, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{})
+	if err != nil {
+		t.Fatalf("ConvertDirectoryFS failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("file %s: unexpected error: %v", r.Path, r.Err)
+		}
+	}
+}
+
+// TestConvertDirectoryFSCompressLAZWithoutTools verifies ConvertOptions.Compress
+// surfaces a clear per-file error when LAZ output is requested but neither
+// laszip nor pdal is on PATH, instead of silently writing a plain .las file.
+func TestConvertDirectoryFSCompressLAZWithoutTools(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+`))
+
+	results, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{
+		Compress: las.CompressionLAZ,
+	})
+	if err == nil {
+		t.Fatal("expected an error when no LAZ compressor is available, got nil")
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+// TestConvertDirectoryFSTimeout verifies an unreasonably short Timeout
+// causes SkipErrors-less conversion to fail with a timeout error instead
+// of blocking forever.
+func TestConvertDirectoryFSTimeout(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(`Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,4
+`))
+
+	_, err := ConvertDirectoryFS(context.Background(), fsys, "input", "output", ConvertOptions{
+		Timeout: time.Nanosecond,
+	})
+	if err == nil {
+		t.Fatal("expected a timeout error, got nil")
+	}
+}
@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"math"
 	"os"
 	"path/filepath"
@@ -131,13 +132,21 @@ func TestConvertDirectory(t *testing.T) {
 	}
 
 	// Convert all
-	count, err := ConvertDirectory(inputDir, outputDir)
+	results, err := ConvertDirectory(context.Background(), inputDir, outputDir, ConvertOptions{})
 	if err != nil {
 		t.Fatalf("ConvertDirectory failed: %v", err)
 	}
 
-	if count != 3 {
-		t.Errorf("Expected 3 files converted, got %d", count)
+	if len(results) != 3 {
+		t.Errorf("Expected 3 files converted, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("file %s: unexpected error: %v", r.Path, r.Err)
+		}
+		if r.PointsWritten != 1 {
+			t.Errorf("file %s: PointsWritten = %d, want 1", r.Path, r.PointsWritten)
+		}
 	}
 
 	// Check all LAS files exist
@@ -149,6 +158,58 @@ func TestConvertDirectory(t *testing.T) {
 	}
 }
 
+// TestConvertDirectorySkipErrors verifies that a bad file doesn't abort the
+// whole batch when SkipErrors is set, and that its failure is surfaced in
+// that file's FileResult instead.
+func TestConvertDirectorySkipErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	goodCSV := `Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,4
+`
+	badCSV := "Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount\nmissing,columns\n"
+
+	if err := os.WriteFile(filepath.Join(inputDir, "good.csv"), []byte(goodCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "bad.csv"), []byte(badCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ConvertDirectory(context.Background(), inputDir, outputDir, ConvertOptions{SkipErrors: true})
+	if err != nil {
+		t.Fatalf("ConvertDirectory with SkipErrors failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	var sawFailure, sawSuccess bool
+	for _, r := range results {
+		if r.Err != nil {
+			sawFailure = true
+		} else {
+			sawSuccess = true
+		}
+	}
+	if !sawFailure {
+		t.Error("Expected bad.csv to report an error in its FileResult")
+	}
+	if !sawSuccess {
+		t.Error("Expected good.csv to convert successfully")
+	}
+
+	if _, err := ConvertDirectory(context.Background(), inputDir, outputDir, ConvertOptions{}); err == nil {
+		t.Error("Expected ConvertDirectory without SkipErrors to return an error for the batch")
+	}
+}
+
 // TestColorAssignment tests PassCount-based color assignment
 func TestColorAssignment(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"math"
+	"strconv"
+
+	"compactmapper/las"
+)
+
+// buildExtraAttributes reads the CAT-roller compaction telemetry columns
+// las.CompactionExtraByteFields describes straight out of fields (the
+// same raw-string row-by-column map ColorMapper.Color already receives),
+// for a parseCSVPoint call with extraBytes enabled.
+func buildExtraAttributes(fields map[string]string) *las.ExtraAttributes {
+	return &las.ExtraAttributes{
+		LastCMV:     parseFloatOrNaN(fields["LastCMV"]),
+		TargCMV:     parseFloatOrNaN(fields["TargCMV"]),
+		LastMDP:     parseFloatOrNaN(fields["LastMDP"]),
+		LastEVIB1:   parseFloatOrNaN(fields["LastEVIB1"]),
+		LastEVIB2:   parseFloatOrNaN(fields["LastEVIB2"]),
+		PassCount:   parseFloatOrNaN(fields["PassCount"]),
+		TotalPasses: parseFloatOrNaN(fields["TotalPasses"]),
+		LastTemp:    parseFloatOrNaN(fields["LastTemp"]),
+	}
+}
+
+// parseFloatOrNaN mirrors sorter's helper of the same name: a blank or
+// "?" cell (CAT-roller's sentinel for "not yet computed") parses to NaN
+// rather than a fatal error, since these fields are supplementary
+// telemetry attached to the point cloud, not part of its required
+// geometry.
+func parseFloatOrNaN(s string) float64 {
+	if s == "" || s == "?" {
+		return math.NaN()
+	}
+	// Some of these columns carry a unit suffix; parse only the leading
+	// numeric run.
+	end := len(s)
+	for i, r := range s {
+		if r == '.' || r == '-' || r == '+' || (r >= '0' && r <= '9') {
+			continue
+		}
+		end = i
+		break
+	}
+	v, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+//go:build fuzz
+
+package converter
+
+import (
+	"math"
+	"testing"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// FuzzConvertCSV fuzzes ConvertCSVToLASFS's CSV parsing with Go's native
+// fuzzing, seeded from the same hand-picked edge cases
+// converter_test.go/converter_fs_test.go already cover (missing
+// columns, non-numeric fields, "?" sentinels, a BOM). Real-world field
+// logs this is meant to stand in for - truncated exports, stray binary
+// bytes, gigantic or negative coordinates - aren't available to fetch
+// in this environment, so the fuzzer's own byte-flipping is what
+// exercises those shapes instead.
+//
+// It asserts ConvertCSVToLASFS never panics and, when it succeeds,
+// that the in-memory output is a non-empty, readable LAS file whose
+// points all have finite X/Y/Z coordinates.
+//
+// Run with: go test -tags fuzz -fuzz FuzzConvertCSV ./internal/converter
+func FuzzConvertCSV(f *testing.F) {
+	seeds := []string{
+		"Time,CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount\n" +
+			"2025/Oct/01 09:30:02.800,200.3,100.5,5.2,2,4\n",
+		"\xEF\xBB\xBFTime,CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount\n" +
+			"2025/Oct/01 09:30:02.800,200.3,100.5,5.2,2,4\n",
+		"Time,CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount\n" +
+			"2025/Oct/01 09:30:02.800,not-a-number,100.5,5.2,2,4\n",
+		"Time,CellE_m,CellN_m,Elevation_m,PassCount,TargPassCount\n" +
+			"2025/Oct/01 09:30:02.800,200.3,100.5,5.2,?,4\n",
+		"DesignName,LastAmp\nDesign1,0.97\n",
+		"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, csvContent string) {
+		fsys := fsutil.NewMemFilesystem()
+		fsys.WriteFile("input/fuzz.csv", []byte(csvContent))
+
+		err := ConvertCSVToLASFS(fsys, "input/fuzz.csv", "output", false, nil, nil, nil)
+		if err != nil {
+			return
+		}
+
+		data, statErr := fsys.Stat("output/fuzz.las")
+		if statErr != nil {
+			t.Fatalf("ConvertCSVToLASFS reported success but output/fuzz.las doesn't exist: %v", statErr)
+		}
+		if data.Size() == 0 {
+			t.Fatalf("ConvertCSVToLASFS reported success but output/fuzz.las is empty")
+		}
+
+		reader, err := las.NewReaderFS(fsys, "output/fuzz.las")
+		if err != nil {
+			t.Fatalf("NewReaderFS failed to reopen ConvertCSVToLASFS's own output: %v", err)
+		}
+		defer reader.Close()
+
+		points, err := reader.ReadPoints()
+		if err != nil {
+			t.Fatalf("ReadPoints failed on ConvertCSVToLASFS's own output: %v", err)
+		}
+		for _, p := range points {
+			if math.IsNaN(p.X) || math.IsInf(p.X, 0) ||
+				math.IsNaN(p.Y) || math.IsInf(p.Y, 0) ||
+				math.IsNaN(p.Z) || math.IsInf(p.Z, 0) {
+				t.Errorf("point has a non-finite coordinate: %+v", p)
+			}
+		}
+	})
+}
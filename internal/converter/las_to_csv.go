@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"compactmapper/las"
+)
+
+// csvHeader is the column order ConvertLASToCSV writes. It mirrors the
+// required columns ConvertCSVToLAS reads, so the two are round-trippable.
+var csvHeader = []string{"Time", "CellE_m", "CellN_m", "Elevation_m", "PassCount", "TargPassCount"}
+
+// gpsTimeToTimeStr is the inverse of parseTimeToGPS: it renders a GPS time
+// (Adjusted Standard GPS Time, i.e. a Unix timestamp) back into the CSV's
+// "2025/Oct/01 09:31:49.500" layout.
+func gpsTimeToTimeStr(gpsTime float64) string {
+	sec := int64(gpsTime)
+	nsec := int64(math.Round((gpsTime - float64(sec)) * 1e9))
+	if nsec == 1e9 {
+		sec++
+		nsec = 0
+	}
+	return time.Unix(sec, nsec).UTC().Format("2006/Jan/02 15:04:05.000")
+}
+
+// passCountFromColor inverts determineColor. The red/green/blue classifier
+// collapses every under/at/over-target ratio to one of three colors, so
+// this can only recover a representative PassCount/TargPassCount pair for
+// each bucket rather than the original counts - round-tripping through LAS
+// and back is lossy for PassCount, while coordinates come back to within
+// the LAS scale factor and GPS time to within a millisecond.
+func passCountFromColor(r, g, b uint16) (passCount, targPass int) {
+	const targPassCount = 4
+	switch {
+	case g == 65535 && r == 0 && b == 0:
+		return targPassCount, targPassCount // at target
+	case r == 65535 && g == 0 && b == 0:
+		return targPassCount - 1, targPassCount // under target
+	default:
+		return targPassCount + 1, targPassCount // over target
+	}
+}
+
+// ConvertLASToCSV reads a LAS file and writes an equivalent CSV using the
+// column layout ConvertCSVToLAS expects, so the converter can act as a
+// general (if lossy on PassCount) interchange utility rather than a
+// one-way tool.
+func ConvertLASToCSV(lasPath, outputDir string) error {
+	reader, err := las.NewReader(lasPath)
+	if err != nil {
+		return fmt.Errorf("error opening LAS file: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		return fmt.Errorf("error reading LAS points: %v", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	baseName := filepath.Base(lasPath)
+	csvName := baseName[:len(baseName)-4] + ".csv"
+	outputPath := filepath.Join(outputDir, csvName)
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("error creating CSV file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %v", err)
+	}
+
+	for _, pt := range points {
+		passCount, targPass := passCountFromColor(pt.R, pt.G, pt.B)
+		row := []string{
+			gpsTimeToTimeStr(pt.GPSTime),
+			strconv.FormatFloat(pt.X, 'f', -1, 64),
+			strconv.FormatFloat(pt.Y, 'f', -1, 64),
+			strconv.FormatFloat(pt.Z, 'f', -1, 64),
+			strconv.Itoa(passCount),
+			strconv.Itoa(targPass),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing CSV row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %v", err)
+	}
+
+	return nil
+}
+
+// RoundtripConvert converts the CSV at csvPath to LAS and back to CSV, both
+// under workDir, and returns the path of the resulting CSV. It exists to
+// give the test suite (and callers probing for data loss) a single entry
+// point that exercises CSV -> LAS -> CSV without wiring up the two
+// converters by hand.
+func RoundtripConvert(csvPath, workDir string) (string, error) {
+	lasDir := filepath.Join(workDir, "las")
+	csvOutDir := filepath.Join(workDir, "csv")
+
+	if err := ConvertCSVToLAS(csvPath, lasDir); err != nil {
+		return "", fmt.Errorf("csv to las: %v", err)
+	}
+
+	baseName := filepath.Base(csvPath)
+	lasName := baseName[:len(baseName)-4] + ".las"
+	lasPath := filepath.Join(lasDir, lasName)
+
+	if err := ConvertLASToCSV(lasPath, csvOutDir); err != nil {
+		return "", fmt.Errorf("las to csv: %v", err)
+	}
+
+	csvName := lasName[:len(lasName)-4] + ".csv"
+	return filepath.Join(csvOutDir, csvName), nil
+}
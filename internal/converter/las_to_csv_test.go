@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestConvertLASToCSV verifies the reverse converter maps LAS fields back
+// onto the CSV columns ConvertCSVToLAS produced them from.
+func TestConvertLASToCSV(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasDir := filepath.Join(tmpDir, "las")
+	csvOutDir := filepath.Join(tmpDir, "csv")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,100.5,200.3,5.2,2,4
+2025/Oct/01 09:30:03.000,100.6,200.4,5.3,4,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConvertCSVToLAS(testCSV, lasDir); err != nil {
+		t.Fatalf("ConvertCSVToLAS failed: %v", err)
+	}
+
+	lasPath := filepath.Join(lasDir, "test.las")
+	if err := ConvertLASToCSV(lasPath, csvOutDir); err != nil {
+		t.Fatalf("ConvertLASToCSV failed: %v", err)
+	}
+
+	rows := readCSVRows(t, filepath.Join(csvOutDir, "test.csv"))
+	if len(rows) != 3 { // header + 2 data rows
+		t.Fatalf("Expected 3 lines (header + 2 data), got %d", len(rows))
+	}
+	if rows[0][0] != "Time" {
+		t.Errorf("Header[0] = %q, want %q", rows[0][0], "Time")
+	}
+}
+
+// TestRoundtripConvert verifies CSV -> LAS -> CSV preserves coordinates and
+// GPS time within LAS's scaling tolerance.
+func TestRoundtripConvert(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount
+2025/Oct/01 09:30:02.800,2031878.930,91550.610,5.372,2,4
+2025/Oct/01 09:31:49.500,2031879.270,91550.900,5.401,4,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resultCSV, err := RoundtripConvert(testCSV, tmpDir)
+	if err != nil {
+		t.Fatalf("RoundtripConvert failed: %v", err)
+	}
+
+	rows := readCSVRows(t, resultCSV)
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 lines (header + 2 data), got %d", len(rows))
+	}
+
+	wantCoords := [][3]float64{
+		{91550.610, 2031878.930, 5.372},
+		{91550.900, 2031879.270, 5.401},
+	}
+	wantGPSTimes := []float64{1759311002.800, 1759311109.500}
+
+	const coordTolerance = 0.001 // LAS scale factor is 0.001 units
+	const gpsTolerance = 0.001   // 1ms
+
+	for i, want := range wantCoords {
+		row := rows[i+1]
+		x, _ := strconv.ParseFloat(row[1], 64)
+		y, _ := strconv.ParseFloat(row[2], 64)
+		z, _ := strconv.ParseFloat(row[3], 64)
+
+		if math.Abs(x-want[0]) > coordTolerance {
+			t.Errorf("Row %d: X = %f, want %f", i, x, want[0])
+		}
+		if math.Abs(y-want[1]) > coordTolerance {
+			t.Errorf("Row %d: Y = %f, want %f", i, y, want[1])
+		}
+		if math.Abs(z-want[2]) > coordTolerance {
+			t.Errorf("Row %d: Z = %f, want %f", i, z, want[2])
+		}
+
+		gpsTime, err := parseTimeToGPS(row[0])
+		if err != nil {
+			t.Fatalf("Row %d: could not parse round-tripped time %q: %v", i, row[0], err)
+		}
+		if math.Abs(gpsTime-wantGPSTimes[i]) > gpsTolerance {
+			t.Errorf("Row %d: GPSTime = %f, want %f", i, gpsTime, wantGPSTimes[i])
+		}
+	}
+}
+
+func readCSVRows(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	return rows
+}
@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Reprojector transforms CellE_m/CellN_m/Elevation_m triples from one
+// coordinate reference system to another. Since this repo vendors no CRS
+// library (no go-spatial/proj, no PROJ bindings), transformation is
+// delegated to the external cs2cs command-line tool (part of PROJ),
+// driven as a long-lived subprocess so a multi-hundred-thousand-row CSV
+// doesn't spawn a process per point.
+type Reprojector struct {
+	cmd      *exec.Cmd
+	stdinRaw io.WriteCloser
+	stdin    *bufio.Writer
+	stdout   *bufio.Reader
+}
+
+// NewReprojector starts a cs2cs subprocess transforming coordinates from
+// sourceEPSG to targetEPSG. Returns an error naming cs2cs if it isn't on
+// PATH - reprojection is the one optional piece of CRS support that
+// can't degrade gracefully, since producing plausible-looking but wrong
+// coordinates would be worse than failing the conversion.
+func NewReprojector(sourceEPSG, targetEPSG int) (*Reprojector, error) {
+	path, err := exec.LookPath("cs2cs")
+	if err != nil {
+		return nil, fmt.Errorf("reprojection requires cs2cs (part of PROJ) on PATH: %v", err)
+	}
+
+	cmd := exec.Command(path,
+		"+init=epsg:"+strconv.Itoa(sourceEPSG), "+to", "+init=epsg:"+strconv.Itoa(targetEPSG),
+		"-f", "%.9f",
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening cs2cs stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening cs2cs stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting cs2cs: %v", err)
+	}
+
+	return &Reprojector{cmd: cmd, stdinRaw: stdin, stdin: bufio.NewWriter(stdin), stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Transform reprojects a single (x, y, z) point. z passes through
+// cs2cs unchanged for horizontal-only EPSG pairs, which covers every
+// CRS this package bundles (see las.EPSGWKT).
+func (r *Reprojector) Transform(x, y, z float64) (float64, float64, float64, error) {
+	if _, err := fmt.Fprintf(r.stdin, "%.9f %.9f %.9f\n", x, y, z); err != nil {
+		return 0, 0, 0, fmt.Errorf("error writing to cs2cs: %v", err)
+	}
+	if err := r.stdin.Flush(); err != nil {
+		return 0, 0, 0, fmt.Errorf("error flushing cs2cs input: %v", err)
+	}
+
+	line, err := r.stdout.ReadString('\n')
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error reading cs2cs output: %v", err)
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0, fmt.Errorf("unexpected cs2cs output: %q", line)
+	}
+	outX, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error parsing cs2cs X output %q: %v", fields[0], err)
+	}
+	outY, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error parsing cs2cs Y output %q: %v", fields[1], err)
+	}
+	outZ, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error parsing cs2cs Z output %q: %v", fields[2], err)
+	}
+
+	return outX, outY, outZ, nil
+}
+
+// Close signals end-of-input and waits for the cs2cs subprocess to exit.
+func (r *Reprojector) Close() error {
+	if err := r.stdin.Flush(); err != nil {
+		return err
+	}
+	if err := r.stdinRaw.Close(); err != nil {
+		return err
+	}
+	return r.cmd.Wait()
+}
@@ -0,0 +1,112 @@
+package converter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/internal/tiledpoints"
+	"compactmapper/las"
+)
+
+// ConvertCSVToTiledFS converts csvPath into an octree-tiled directory of
+// plain LAS files instead of one flat LAS file - see package
+// tiledpoints' doc comment for exactly what this tile format is (and
+// isn't: it's not COPC or any other existing spec). Row parsing,
+// skipErrors, bounds and colorMapper all behave identically to
+// ConvertCSVToLASFS; maxPointsPerNode is forwarded to
+// tiledpoints.BuildOctree (<= 0 selects tiledpoints.DefaultMaxPointsPerNode).
+// One difference: when every row is skipped, it returns the "no points to
+// write" error even with skipErrors set.
+// Unlike ConvertCSVToLASFS, the whole file's points are buffered in
+// memory before any output is written, since BuildOctree needs every
+// point's coordinates up front to partition them - the same tradeoff
+// convertCSVToLASCounted's buffered las.Writer path already makes for
+// any LASFormat/CRS/extra-bytes/compression combination StreamWriter
+// doesn't cover. Output is written to outputDir/<csv base name>/, named
+// after the leaf octree key, plus outputDir/<csv base name>/hierarchy.json.
+func ConvertCSVToTiledFS(fsys fsutil.Filesystem, csvPath, outputDir string, skipErrors bool, errorLog *os.File, bounds *BBox, colorMapper ColorMapper, maxPointsPerNode int) (int, error) {
+	if colorMapper == nil {
+		colorMapper = PassCountClassifier{}
+	}
+
+	file, err := fsys.Open(csvPath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening CSV file: %v", err)
+	}
+	defer func() { _ = file.Close() }() // Read-only file; close error is non-actionable
+
+	reader := csv.NewReader(file)
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("CSV file is empty or has no data rows")
+	}
+
+	colMap := make(map[string]int)
+	for i, col := range header {
+		colMap[col] = i
+	}
+
+	required := []string{"Time", "CellE_m", "CellN_m", "Elevation_m", "PassCount", "TargPassCount"}
+	for _, col := range required {
+		if _, ok := colMap[col]; !ok {
+			return 0, fmt.Errorf("missing required column: %s", col)
+		}
+	}
+
+	baseName := filepath.Base(csvPath)
+
+	var points []las.Point
+	skipped := 0
+
+	for rowNum := 2; ; rowNum++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if skipErrors {
+				skipped++
+				logConvertSkip(errorLog, baseName, rowNum, "", "", err)
+				continue
+			}
+			return 0, fmt.Errorf("row %d: error reading row: %v", rowNum, err)
+		}
+
+		pt, skipErr := parseCSVPoint(row, colMap, bounds, colorMapper, false)
+		if skipErr != nil {
+			if skipErrors {
+				skipped++
+				logConvertSkip(errorLog, baseName, rowNum, skipErr.column, skipErr.raw, skipErr.err)
+				continue
+			}
+			return 0, fmt.Errorf("row %d: %s", rowNum, skipErr.err)
+		}
+
+		points = append(points, pt)
+	}
+
+	if skipErrors && skipped > 0 && errorLog != nil {
+		fmt.Fprintf(errorLog, "File: %s - Total skipped rows during conversion: %d\n", baseName, skipped)
+	}
+
+	if len(points) == 0 {
+		return 0, fmt.Errorf("no points to write")
+	}
+
+	if err := fsys.MkdirAll(outputDir, 0755); err != nil {
+		return 0, fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	tileDir := filepath.Join(outputDir, baseName[:len(baseName)-4])
+	root := tiledpoints.BuildOctree(tiledpoints.ToPoints(points), maxPointsPerNode)
+	if _, err := tiledpoints.WriteTiles(fsys, tileDir, root, points); err != nil {
+		return 0, fmt.Errorf("error writing tiles: %v", err)
+	}
+
+	return len(points), nil
+}
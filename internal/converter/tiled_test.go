@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/internal/tiledpoints"
+)
+
+// TestConvertCSVToTiledFSWritesTiledOutput converts a CSV with enough
+// rows to force the octree to split, and verifies the output directory
+// holds more than one tile LAS file plus a hierarchy.json accounting for
+// every point.
+func TestConvertCSVToTiledFSWritesTiledOutput(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&sb, "2025/Oct/01 09:30:%02d.000,%d,%d,5.2,2,4\n", i%60, 100+i%5, 200+i/5)
+	}
+
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/test.csv", []byte(sb.String()))
+
+	written, err := ConvertCSVToTiledFS(fsys, "input/test.csv", "output", false, nil, nil, nil, 5)
+	if err != nil {
+		t.Fatalf("ConvertCSVToTiledFS failed: %v", err)
+	}
+	if written != 20 {
+		t.Errorf("written = %d, want 20", written)
+	}
+
+	manifest, err := fsys.Open("output/test/hierarchy.json")
+	if err != nil {
+		t.Fatalf("hierarchy.json was not written: %v", err)
+	}
+	defer manifest.Close()
+
+	var hierarchy tiledpoints.Hierarchy
+	if err := json.NewDecoder(manifest).Decode(&hierarchy); err != nil {
+		t.Fatalf("hierarchy.json isn't valid JSON: %v", err)
+	}
+	if len(hierarchy.Nodes) <= 1 {
+		t.Fatalf("expected more than one tile for 20 points over a limit of 5, got %d", len(hierarchy.Nodes))
+	}
+
+	total := 0
+	for _, node := range hierarchy.Nodes {
+		if _, err := fsys.Stat("output/test/" + node.File); err != nil {
+			t.Errorf("expected tile file output/test/%s to exist: %v", node.File, err)
+		}
+		total += node.PointCount
+	}
+	if total != 20 {
+		t.Errorf("tiles account for %d points total, want 20", total)
+	}
+}
+
+// TestConvertCSVToTiledFSNoRows verifies an all-skipped CSV produces the
+// same "no points to write" error as the flat-file converter, rather
+// than writing an empty tile directory.
+func TestConvertCSVToTiledFSNoRows(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	fsys.WriteFile("input/empty.csv", []byte("Time,CellN_m,CellE_m,Elevation_m,PassCount,TargPassCount\n"))
+
+	_, err := ConvertCSVToTiledFS(fsys, "input/empty.csv", "output", false, nil, nil, nil, 5)
+	if err == nil || !strings.Contains(err.Error(), "no points to write") {
+		t.Errorf("expected a 'no points to write' error, got %v", err)
+	}
+}
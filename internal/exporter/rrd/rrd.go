@@ -0,0 +1,277 @@
+// Package rrd fans compaction telemetry rows out into per-group
+// Round-Robin Databases, so a shift's pass counts, CMV drift, and
+// vibration state can be graphed by any RRDtool-aware dashboard without
+// loading the equivalent LAS point clouds.
+//
+// The request behind this package asked for github.com/ziutek/rrd's cgo
+// binding to librrd. This tree has no go.mod, no module cache, and no
+// network access to vendor that or any other external module - the same
+// situation las.WriteLAZ (LASzip) and converter.reproject (PROJ) were
+// already in, and both of those bridge the gap by shelling out to an
+// external CLI that's expected to be installed separately rather than
+// vendored. Exporter does the same thing here: it shells out to the
+// rrdtool CLI's "create"/"update" subcommands, and Info/Fetch wrap
+// "info"/"fetch". Anywhere rrdtool is on PATH, this produces the same
+// .rrd files ziutek/rrd's binding would, since that binding itself wraps
+// librrd - the same engine the CLI links against.
+package rrd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"compactmapper/internal/sorter"
+)
+
+// stepSeconds is the RRA step every Exporter database is created with:
+// one sample every 30 seconds, matching the interval CAT-roller exports
+// are typically sampled at.
+const stepSeconds = 30
+
+// dataSources and archives are the DS/RRA definitions every database
+// Exporter creates is given. They're fixed rather than configurable
+// because every .rrd this package writes is meant to be interchangeable
+// with any other - a dashboard built against one group's database works
+// unmodified against any other's.
+var (
+	dataSources = []string{
+		fmt.Sprintf("DS:cmv:GAUGE:%d:U:U", 2*stepSeconds),
+		fmt.Sprintf("DS:mdp:GAUGE:%d:U:U", 2*stepSeconds),
+		fmt.Sprintf("DS:evib1:GAUGE:%d:U:U", 2*stepSeconds),
+		fmt.Sprintf("DS:passcount:COUNTER:%d:0:U", 2*stepSeconds),
+		fmt.Sprintf("DS:speed:GAUGE:%d:U:U", 2*stepSeconds),
+		fmt.Sprintf("DS:temp:GAUGE:%d:U:U", 2*stepSeconds),
+	}
+	archives = []string{
+		"RRA:AVERAGE:0.5:1:2880", // 24h at the native 30s step
+		"RRA:AVERAGE:0.5:60:672", // 30min rollup
+		"RRA:MAX:0.5:60:672",     // 30min rollup, peak rather than mean
+	}
+	updateTemplate = "cmv:mdp:evib1:passcount:speed:temp"
+)
+
+var invalidFilenameChars = regexp.MustCompile(`[<>:"/\\|?*]`)
+
+// Exporter implements sorter.Exporter, writing one .rrd per
+// Date x DesignName x LastAmp x Machine combination into OutDir.
+type Exporter struct {
+	outDir string
+	tool   string
+
+	mu      sync.Mutex
+	created map[string]bool
+	// updates holds one mutex per database path, held around that
+	// database's rrdtool update invocations.
+	updates map[string]*sync.Mutex
+}
+
+// NewExporter creates outDir if necessary and resolves rrdtool on PATH,
+// returning an error immediately if it isn't found rather than failing
+// later on the first Export call.
+func NewExporter(outDir string) (*Exporter, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating rrd output directory: %v", err)
+	}
+	tool, err := exec.LookPath("rrdtool")
+	if err != nil {
+		return nil, fmt.Errorf("rrdtool not found on PATH: %v", err)
+	}
+	return &Exporter{
+		outDir:  outDir,
+		tool:    tool,
+		created: make(map[string]bool),
+		updates: make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// Export creates row's group database on first use, then appends row to
+// it. Concurrent calls for different groups proceed independently;
+// concurrent calls for the *same* group serialize on e.mu around
+// creation and on a per-database mutex around the rrdtool update, so
+// two updates of one database never run at once. Which of two such
+// concurrent rows reaches the database first is not defined.
+func (e *Exporter) Export(key sorter.GroupKey, row sorter.Row) error {
+	path := e.path(key, row.Machine)
+
+	e.mu.Lock()
+	pathMu := e.updates[path]
+	if pathMu == nil {
+		pathMu = &sync.Mutex{}
+		e.updates[path] = pathMu
+	}
+	exists := e.created[path]
+	if !exists {
+		if _, statErr := os.Stat(path); statErr == nil {
+			exists = true
+		}
+	}
+	if !exists {
+		if err := e.create(path, row.Time); err != nil {
+			e.mu.Unlock()
+			return err
+		}
+		e.created[path] = true
+	}
+	e.mu.Unlock()
+
+	pathMu.Lock()
+	defer pathMu.Unlock()
+	return e.update(path, row)
+}
+
+// Close is a no-op: Export shells out to rrdtool synchronously for every
+// row, so there's no buffered state to flush. It exists to satisfy
+// sorter.Exporter.
+func (e *Exporter) Close() error {
+	return nil
+}
+
+// path returns the .rrd file for key and machine, sanitized the same
+// way sorter's own group filenames are.
+func (e *Exporter) path(key sorter.GroupKey, machine string) string {
+	if machine == "" {
+		machine = "unknown"
+	}
+	filename := fmt.Sprintf("%sdesign%samp%smachine%s.rrd",
+		key.Date, invalidFilenameChars.ReplaceAllString(key.DesignName, ""),
+		key.Amp, invalidFilenameChars.ReplaceAllString(machine, ""))
+	return filepath.Join(e.outDir, filename)
+}
+
+// create runs `rrdtool create` for path, starting the database one
+// second before start rounded down to the step (the timestamp update
+// writes for that row) so the first `rrdtool update` call (which must
+// land strictly after the database's start time) always succeeds.
+func (e *Exporter) create(path string, start time.Time) error {
+	first := start.Unix()
+	first -= first % stepSeconds
+	args := append([]string{
+		"create", path,
+		"--start", strconv.FormatInt(first-1, 10),
+		"--step", strconv.Itoa(stepSeconds),
+	}, dataSources...)
+	args = append(args, archives...)
+
+	out, err := exec.Command(e.tool, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rrdtool create %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// update runs `rrdtool update` for path, rounding row.Time down to the
+// database's step (rrdtool update applies last-writer-wins to a
+// duplicate timestamp within the same step, which is the behavior we
+// want when two rows fall in the same 30s bucket).
+func (e *Exporter) update(path string, row sorter.Row) error {
+	ts := row.Time.Unix()
+	ts -= ts % stepSeconds
+
+	value := fmt.Sprintf("%d:%s:%s:%s:%s:%s:%s", ts,
+		formatValue(row.CMV), formatValue(row.MDP), formatValue(row.EVIB1),
+		formatValue(row.PassCount), formatValue(row.Speed), formatValue(row.Temp))
+
+	out, err := exec.Command(e.tool, "update", path, "--template", updateTemplate, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rrdtool update %s: %v: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// formatValue renders v the way rrdtool update expects: "U" (Unknown)
+// for NaN, rrdtool's own marker for a missing sample, and a plain
+// decimal otherwise.
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return "U"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Info runs `rrdtool info` against path and returns its key=value
+// output (e.g. "last_update", "step", "ds[cmv].type") as a map.
+func Info(path string) (map[string]string, error) {
+	tool, err := exec.LookPath("rrdtool")
+	if err != nil {
+		return nil, fmt.Errorf("rrdtool not found on PATH: %v", err)
+	}
+	out, err := exec.Command(tool, "info", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("rrdtool info %s: %v", path, err)
+	}
+
+	info := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		info[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return info, nil
+}
+
+// FetchResult is one data source's series as returned by Fetch.
+type FetchResult struct {
+	Timestamps []int64
+	Values     map[string][]float64
+}
+
+// Fetch runs `rrdtool fetch path AVERAGE` and parses the timestamp/value
+// rows it prints for the database's full time window.
+func Fetch(path string) (*FetchResult, error) {
+	tool, err := exec.LookPath("rrdtool")
+	if err != nil {
+		return nil, fmt.Errorf("rrdtool not found on PATH: %v", err)
+	}
+	out, err := exec.Command(tool, "fetch", path, "AVERAGE").Output()
+	if err != nil {
+		return nil, fmt.Errorf("rrdtool fetch %s: %v", path, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("unexpected rrdtool fetch output for %s: empty", path)
+	}
+
+	dsNames := strings.Fields(lines[0])
+	result := &FetchResult{Values: make(map[string][]float64, len(dsNames))}
+
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		ts, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(strings.TrimSpace(ts), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) != len(dsNames) {
+			continue
+		}
+		result.Timestamps = append(result.Timestamps, timestamp)
+		for i, name := range dsNames {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				v = math.NaN()
+			}
+			result.Values[name] = append(result.Values[name], v)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package rrd
+
+import (
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"compactmapper/internal/sorter"
+)
+
+// TestNewExporterWithoutRRDToolFails verifies NewExporter surfaces a
+// clear error when rrdtool isn't on PATH, rather than failing later on
+// the first Export call.
+func TestNewExporterWithoutRRDToolFails(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	if _, err := NewExporter(t.TempDir()); err == nil {
+		t.Fatal("expected an error when rrdtool is not available, got nil")
+	}
+}
+
+// TestExportCreatesAndUpdatesDatabase verifies Export creates one .rrd
+// per group, and that rrd.Info/rrd.Fetch report the data Export wrote.
+// It's skipped when rrdtool isn't installed, since this package has no
+// other way to validate the RRD binary format it shells out to produce.
+func TestExportCreatesAndUpdatesDatabase(t *testing.T) {
+	if _, err := exec.LookPath("rrdtool"); err != nil {
+		t.Skip("rrdtool not found on PATH; skipping")
+	}
+
+	outDir := t.TempDir()
+	exporter, err := NewExporter(outDir)
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+	defer exporter.Close()
+
+	key := sorter.GroupKey{Date: "2025-10-01", DesignName: "Design1", Amp: "097"}
+	base := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	rows := []sorter.Row{
+		{Time: base, Machine: "Machine1", CMV: 40.0, MDP: 50.0, EVIB1: math.NaN(), PassCount: 1, Speed: 0.8, Temp: 22.5},
+		{Time: base.Add(time.Minute), Machine: "Machine1", CMV: 41.5, MDP: 50.5, EVIB1: math.NaN(), PassCount: 2, Speed: 0.9, Temp: 22.6},
+	}
+	for _, row := range rows {
+		if err := exporter.Export(key, row); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+	}
+
+	path := filepath.Join(outDir, "2025-10-01designDesign1amp097machineMachine1.rrd")
+
+	info, err := Info(path)
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	lastUpdate := base.Add(time.Minute).Unix()
+	lastUpdate -= lastUpdate % stepSeconds
+	if info["last_update"] != strconv.FormatInt(lastUpdate, 10) {
+		t.Errorf("last_update = %q, want %q", info["last_update"], strconv.FormatInt(lastUpdate, 10))
+	}
+
+	result, err := Fetch(path)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.Timestamps) == 0 {
+		t.Fatal("expected Fetch to return at least one sample")
+	}
+	if _, ok := result.Values["cmv"]; !ok {
+		t.Error("expected a cmv series in the fetch result")
+	}
+}
+
@@ -0,0 +1,58 @@
+// Package fsutil abstracts the filesystem operations the converter, las,
+// and sorter packages need (Open, Create, MkdirAll, Glob, Stat) behind a
+// small Filesystem interface, modeled on the afero "filesystem as an
+// interface" pattern. OSFilesystem backs it with the real disk; tests can
+// swap in MemFilesystem to exercise malformed input, permission errors,
+// and directory edge cases hermetically.
+package fsutil
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ReadSeekCloser is what callers get back from Open. las.Reader needs to
+// seek to the point data offset, so a plain io.ReadCloser isn't enough.
+type ReadSeekCloser interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Filesystem is the subset of filesystem operations the converter and
+// sorter packages need to do their work.
+type Filesystem interface {
+	Open(name string) (ReadSeekCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Glob(pattern string) ([]string, error)
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// OS is the default Filesystem, backed by the real filesystem via os and
+// path/filepath.
+var OS Filesystem = osFilesystem{}
+
+type osFilesystem struct{}
+
+func (osFilesystem) Open(name string) (ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+func (osFilesystem) Create(name string) (io.WriteCloser, error) {
+	return os.Create(name)
+}
+
+func (osFilesystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFilesystem) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (osFilesystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
@@ -0,0 +1,173 @@
+package fsutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemFilesystem is an in-memory Filesystem for hermetic tests: reads,
+// writes, directory creation, and glob matching never touch the real
+// disk.
+type MemFilesystem struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+
+	// DenyCreate, when set, makes Create fail for any path the predicate
+	// returns true for - used to simulate permission errors.
+	DenyCreate func(name string) bool
+}
+
+// NewMemFilesystem creates an empty in-memory filesystem.
+func NewMemFilesystem() *MemFilesystem {
+	return &MemFilesystem{
+		files: make(map[string][]byte),
+		dirs:  map[string]bool{".": true},
+	}
+}
+
+// WriteFile seeds the filesystem with a file and its parent directory.
+// It's the MemFilesystem equivalent of os.WriteFile for test setup.
+func (m *MemFilesystem) WriteFile(name string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clean := filepath.Clean(name)
+	m.files[clean] = append([]byte(nil), data...)
+	m.dirs[filepath.Clean(filepath.Dir(clean))] = true
+}
+
+func (m *MemFilesystem) Open(name string) (ReadSeekCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{Reader: bytes.NewReader(data)}, nil
+}
+
+func (m *MemFilesystem) Create(name string) (io.WriteCloser, error) {
+	if m.DenyCreate != nil && m.DenyCreate(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrPermission}
+	}
+	return &memWriter{fsys: m, name: filepath.Clean(name)}, nil
+}
+
+func (m *MemFilesystem) MkdirAll(path string, _ os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dirs[filepath.Clean(path)] = true
+	return nil
+}
+
+func (m *MemFilesystem) Glob(pattern string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var matches []string
+	for name := range m.files {
+		ok, err := filepath.Match(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (m *MemFilesystem) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clean := filepath.Clean(name)
+	if data, ok := m.files[clean]; ok {
+		return memFileInfo{name: filepath.Base(clean), size: int64(len(data))}, nil
+	}
+	if m.dirs[clean] {
+		return memFileInfo{name: filepath.Base(clean), isDir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// memFile adapts a bytes.Reader to ReadSeekCloser.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (m *memFile) Close() error { return nil }
+
+// memWriter buffers writes and commits them to the filesystem on Close,
+// mirroring os.Create's "truncate on success" semantics. It also
+// implements io.Seeker over the buffered bytes (Write overwrites in
+// place past the current position rather than always appending), so
+// callers that need to backpatch a placeholder header - e.g.
+// las.NewStreamWriter - work the same way against a MemFilesystem as
+// they do against a real *os.File.
+type memWriter struct {
+	fsys *MemFilesystem
+	name string
+	buf  []byte
+	pos  int
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	end := w.pos + len(p)
+	if end > len(w.buf) {
+		w.buf = append(w.buf, make([]byte, end-len(w.buf))...)
+	}
+	copy(w.buf[w.pos:end], p)
+	w.pos = end
+	return len(p), nil
+}
+
+func (w *memWriter) Seek(offset int64, whence int) (int64, error) {
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = int64(w.pos) + offset
+	case io.SeekEnd:
+		newPos = int64(len(w.buf)) + offset
+	default:
+		return 0, fmt.Errorf("memWriter.Seek: invalid whence %d", whence)
+	}
+	if newPos < 0 {
+		return 0, fmt.Errorf("memWriter.Seek: negative position %d", newPos)
+	}
+	w.pos = int(newPos)
+	return newPos, nil
+}
+
+func (w *memWriter) Close() error {
+	w.fsys.mu.Lock()
+	defer w.fsys.mu.Unlock()
+	w.fsys.files[w.name] = append([]byte(nil), w.buf...)
+	return nil
+}
+
+type memFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (i memFileInfo) Name() string { return i.name }
+func (i memFileInfo) Size() int64  { return i.size }
+func (i memFileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return i.isDir }
+func (i memFileInfo) Sys() any           { return nil }
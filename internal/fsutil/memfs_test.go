@@ -0,0 +1,149 @@
+package fsutil
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMemFilesystemOpenAndCreate(t *testing.T) {
+	fsys := NewMemFilesystem()
+	fsys.WriteFile("input/data.csv", []byte("hello"))
+
+	f, err := fsys.Open("input/data.csv")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("read %q, want %q", data, "hello")
+	}
+
+	w, err := fsys.Create("output/data.csv")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := w.Write([]byte("world")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := fsys.Open("output/data.csv")
+	if err != nil {
+		t.Fatalf("re-opening written file failed: %v", err)
+	}
+	defer r.Close()
+	data, _ = io.ReadAll(r)
+	if string(data) != "world" {
+		t.Errorf("read %q, want %q", data, "world")
+	}
+}
+
+func TestMemFilesystemOpenMissing(t *testing.T) {
+	fsys := NewMemFilesystem()
+	if _, err := fsys.Open("missing.csv"); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
+
+func TestMemFilesystemDenyCreate(t *testing.T) {
+	fsys := NewMemFilesystem()
+	fsys.DenyCreate = func(name string) bool { return name == "locked.las" }
+
+	if _, err := fsys.Create("locked.las"); !os.IsPermission(err) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+	if _, err := fsys.Create("unlocked.las"); err != nil {
+		t.Errorf("unexpected error for unlocked path: %v", err)
+	}
+}
+
+// TestMemFilesystemCreateSeeks verifies the io.WriteCloser returned by
+// Create also supports Seek and overwrites in place rather than
+// appending, the placeholder-then-seek-and-fill pattern las.StreamWriter
+// relies on.
+func TestMemFilesystemCreateSeeks(t *testing.T) {
+	fsys := NewMemFilesystem()
+
+	w, err := fsys.Create("output/data.bin")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	seeker, ok := w.(io.WriteSeeker)
+	if !ok {
+		t.Fatal("expected Create's return value to implement io.WriteSeeker")
+	}
+
+	if _, err := seeker.Write([]byte("XXXXX")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := seeker.Write([]byte("ab")); err != nil {
+		t.Fatalf("overwrite Write failed: %v", err)
+	}
+	if err := seeker.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := fsys.Open("output/data.bin")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "abXXX" {
+		t.Errorf("read %q, want %q", data, "abXXX")
+	}
+}
+
+func TestMemFilesystemGlob(t *testing.T) {
+	fsys := NewMemFilesystem()
+	fsys.WriteFile("dir/a.csv", []byte("a"))
+	fsys.WriteFile("dir/b.csv", []byte("b"))
+	fsys.WriteFile("dir/c.txt", []byte("c"))
+
+	matches, err := fsys.Glob("dir/*.csv")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2: %v", len(matches), matches)
+	}
+}
+
+func TestMemFilesystemStat(t *testing.T) {
+	fsys := NewMemFilesystem()
+	fsys.WriteFile("dir/a.csv", []byte("abc"))
+
+	info, err := fsys.Stat("dir/a.csv")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.IsDir() {
+		t.Error("expected a file, got a directory")
+	}
+	if info.Size() != 3 {
+		t.Errorf("Size() = %d, want 3", info.Size())
+	}
+
+	info, err = fsys.Stat("dir")
+	if err != nil {
+		t.Fatalf("Stat on directory failed: %v", err)
+	}
+	if !info.IsDir() {
+		t.Error("expected a directory")
+	}
+
+	if _, err := fsys.Stat("missing"); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
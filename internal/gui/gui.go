@@ -1,11 +1,14 @@
 package gui
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -19,6 +22,8 @@ import (
 
 	"compactmapper/internal/converter"
 	"compactmapper/internal/sorter"
+	"compactmapper/internal/textenc"
+	"compactmapper/las"
 )
 
 // tappableContainer is a container that can be tapped and shows hover effect
@@ -116,6 +121,11 @@ func Run() {
 	var inputPath, outputPath string
 	var isDirectory bool
 	var skipErrors bool
+	colorMapper := converter.ColorMapper(converter.PassCountClassifier{})
+	lasFormat := converter.LASFormat{}
+	var crs las.CRS
+	var encoding string
+	var timeout time.Duration
 
 	// Title
 	title := widget.NewLabel("CompactMapper")
@@ -218,10 +228,130 @@ func Run() {
 	skipErrorsInfo := widget.NewLabel("When enabled, errors will be logged to err.log in the output folder")
 	skipErrorsInfo.TextStyle = fyne.TextStyle{Italic: true}
 
+	// Color mapping dropdown - picks how PassCount/Elevation data is
+	// turned into point color; see converter.ColorMapper.
+	colorMapSelect := widget.NewSelect(
+		[]string{"Pass count (red/green/blue)", "Pass count ratio (gradient)", "Elevation (gradient)"},
+		func(selected string) {
+			switch selected {
+			case "Pass count ratio (gradient)":
+				colorMapper = converter.RatioGradient{}
+			case "Elevation (gradient)":
+				colorMapper = converter.ElevationGradient{Min: 0, Max: 10}
+			default:
+				colorMapper = converter.PassCountClassifier{}
+			}
+		},
+	)
+	colorMapSelect.SetSelected("Pass count (red/green/blue)")
+
+	colorMapInfo := widget.NewLabel("Controls how points are colored; load a custom palette file via --palette on the CLI")
+	colorMapInfo.TextStyle = fyne.TextStyle{Italic: true}
+
+	// LAS output format dropdown - legacy 1.2/format 3 is still the
+	// default so existing workflows are unaffected; 1.4/format 7 is
+	// offered for tools (QGIS 3.x, PDAL, CloudCompare) that treat
+	// format 3 as legacy.
+	lasFormatSelect := widget.NewSelect(
+		[]string{"LAS 1.2 (legacy, format 3)", "LAS 1.4 (modern, format 7)"},
+		func(selected string) {
+			if selected == "LAS 1.4 (modern, format 7)" {
+				lasFormat = converter.LASFormat{Version: las.Version{Major: 1, Minor: 4}, PointFormat: 7}
+			} else {
+				lasFormat = converter.LASFormat{}
+			}
+		},
+	)
+	lasFormatSelect.SetSelected("LAS 1.2 (legacy, format 3)")
+
+	// Coordinate system entry - a SelectEntry gives autocomplete over the
+	// bundled EPSG list (las.CommonEPSGCodes) while still accepting a
+	// bare EPSG code the user types for a site typed outside that list.
+	epsgLabelToCode := make(map[string]int)
+	var epsgOptions []string
+	for _, code := range las.CommonEPSGCodes() {
+		label := las.EPSGLabel(code)
+		epsgLabelToCode[label] = code
+		epsgOptions = append(epsgOptions, label)
+	}
+	crsEntry := widget.NewSelectEntry(epsgOptions)
+	crsEntry.SetPlaceHolder("EPSG code, e.g. 32610 (leave blank for none)")
+	crsEntry.OnChanged = func(text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			crs = las.CRS{}
+			return
+		}
+		if code, ok := epsgLabelToCode[text]; ok {
+			crs = las.CRS{EPSG: code}
+			return
+		}
+		numeric := strings.TrimPrefix(strings.ToUpper(text), "EPSG:")
+		if code, err := strconv.Atoi(numeric); err == nil {
+			crs = las.CRS{EPSG: code}
+		}
+	}
+
+	crsInfo := widget.NewLabel("Tags output LAS files with a GeoKeyDirectoryTag VLR and a .prj sidecar")
+	crsInfo.TextStyle = fyne.TextStyle{Italic: true}
+
+	// Input encoding dropdown - see textenc.Decode for what each option
+	// does; "Auto-detect" sniffs a byte-order mark and falls back to
+	// plain UTF-8, which is also what leaving this unset does.
+	encodingLabelToName := map[string]string{
+		"Auto-detect (BOM)":      textenc.Auto,
+		"UTF-8 (default)":        "",
+		"Windows-1252 (cp1252)":  "cp1252",
+		"UTF-16 (little-endian)": "utf16le",
+		"UTF-16 (big-endian)":    "utf16be",
+	}
+	encodingSelect := widget.NewSelect(
+		[]string{"UTF-8 (default)", "Auto-detect (BOM)", "Windows-1252 (cp1252)", "UTF-16 (little-endian)", "UTF-16 (big-endian)"},
+		func(selected string) {
+			encoding = encodingLabelToName[selected]
+		},
+	)
+	encodingSelect.SetSelected("UTF-8 (default)")
+
+	encodingInfo := widget.NewLabel("Set this if the source CSVs come from older Windows tooling or Excel's \"Unicode Text\" export")
+	encodingInfo.TextStyle = fyne.TextStyle{Italic: true}
+
+	// Per-file conversion timeout - bounds how long one huge CSV can hold
+	// up the worker pool; see converter.ConvertOptions.Timeout.
+	timeoutEntry := widget.NewEntry()
+	timeoutEntry.SetPlaceHolder("e.g. 30s, 2m (leave blank for no timeout)")
+	timeoutEntry.OnChanged = func(text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			timeout = 0
+			return
+		}
+		if d, err := time.ParseDuration(text); err == nil {
+			timeout = d
+		}
+	}
+
+	timeoutInfo := widget.NewLabel("A file that doesn't finish converting within this long is recorded as failed instead of blocking the batch")
+	timeoutInfo.TextStyle = fyne.TextStyle{Italic: true}
+
 	optionsSection := createSection(container.NewVBox(
 		optionsHeader,
 		skipErrorsCheck,
 		skipErrorsInfo,
+		widget.NewLabel("Color mapping:"),
+		colorMapSelect,
+		colorMapInfo,
+		widget.NewLabel("LAS output format:"),
+		lasFormatSelect,
+		widget.NewLabel("Coordinate system:"),
+		crsEntry,
+		crsInfo,
+		widget.NewLabel("Input encoding:"),
+		encodingSelect,
+		encodingInfo,
+		widget.NewLabel("Per-file timeout:"),
+		timeoutEntry,
+		timeoutInfo,
 	), color.RGBA{R: 128, G: 128, B: 128, A: 255})
 
 	// ===== PROCESSING SECTION =====
@@ -263,13 +393,35 @@ func Run() {
 			// Step 1: Sort CSV files
 			sortedDir := filepath.Join(outputPath, "csv")
 			statusLabel.SetText("Step 1/2: Sorting CSV files...")
-			progressBar.SetValue(0.25)
+			progressBar.SetValue(0)
 
 			var err error
 			if isDirectory {
-				err = sorter.SortCSVDirectory(inputPath, sortedDir, skipErrors, errorLog)
+				// Sorting fans out across a worker pool too, so report
+				// per-file progress over the 0-0.5 range instead of
+				// jumping straight to a fixed increment, same as the
+				// conversion step below.
+				inputFiles, _ := filepath.Glob(filepath.Join(inputPath, "*.csv"))
+				sortTotal := len(inputFiles)
+				sortProgressCh := make(chan sorter.FileResult)
+				sortProgressDone := make(chan struct{})
+				go func() {
+					defer close(sortProgressDone)
+					completed := 0
+					for range sortProgressCh {
+						completed++
+						if sortTotal > 0 {
+							progressBar.SetValue(0.5 * float64(completed) / float64(sortTotal))
+						}
+					}
+				}()
+
+				err = sorter.SortCSVDirectoryWithOptions(inputPath, sortedDir, skipErrors, errorLog, sorter.SortDirectoryOptions{Encoding: encoding, Progress: sortProgressCh})
+				close(sortProgressCh)
+				<-sortProgressDone
 			} else {
-				err = sorter.SortCSV(inputPath, sortedDir, skipErrors, errorLog)
+				progressBar.SetValue(0.25)
+				err = sorter.SortCSVWithOptions(inputPath, sortedDir, skipErrors, errorLog, sorter.SortOptions{Encoding: encoding})
 			}
 
 			if err != nil && !skipErrors {
@@ -284,16 +436,45 @@ func Run() {
 			// Step 2: Convert to LAS
 			lasDir := filepath.Join(outputPath, "las")
 			statusLabel.SetText("Step 2/2: Converting to LAS...")
-			progressBar.SetValue(0.75)
 
-			count, err := converter.ConvertDirectory(sortedDir, lasDir)
-			if err != nil {
+			// Converting fans out across a worker pool, so report
+			// per-file progress over the 0.5-1.0 range instead of
+			// jumping straight to a fixed increment.
+			sortedFiles, _ := filepath.Glob(filepath.Join(sortedDir, "*.csv"))
+			total := len(sortedFiles)
+			progressCh := make(chan converter.FileResult)
+			progressDone := make(chan struct{})
+			go func() {
+				defer close(progressDone)
+				completed := 0
+				for range progressCh {
+					completed++
+					if total > 0 {
+						progressBar.SetValue(0.5 + 0.5*float64(completed)/float64(total))
+					}
+				}
+			}()
+
+			results, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, converter.ConvertOptions{
+				SkipErrors:  skipErrors,
+				Progress:    progressCh,
+				ErrorLog:    errorLog,
+				ColorMapper: colorMapper,
+				LASFormat:   lasFormat,
+				CRS:         crs,
+				Timeout:     timeout,
+			})
+			close(progressCh)
+			<-progressDone
+
+			if err != nil && !skipErrors {
 				progressBar.Hide()
 				dialog.ShowError(fmt.Errorf("conversion failed: %v", err), myWindow)
 				statusLabel.SetText("❌ Error during conversion")
 				return
 			}
 
+			count := len(results)
 			progressBar.SetValue(1.0)
 			statusLabel.SetText(fmt.Sprintf("✅ Complete! Processed %d files", count))
 
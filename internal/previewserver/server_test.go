@@ -0,0 +1,127 @@
+package previewserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+func writeTestLAS(t *testing.T, fsys fsutil.Filesystem, path string, points []las.Point) {
+	t.Helper()
+	w := las.NewWriterFS(fsys)
+	for _, p := range points {
+		w.AddPoint(p)
+	}
+	if err := w.Write(path); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestHandleIndexListsLASFiles(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	writeTestLAS(t, fsys, "output/site1.las", []las.Point{{X: 1, Y: 2, Z: 3, R: 65535}})
+	writeTestLAS(t, fsys, "output/site2.las", []las.Point{{X: 4, Y: 5, Z: 6, G: 65535}})
+
+	srv := NewServer(fsys, "output", 0)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "site1.las") || !strings.Contains(body, "site2.las") {
+		t.Errorf("index page missing file names: %s", body)
+	}
+}
+
+func TestHandlePointsRendersX3D(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	writeTestLAS(t, fsys, "output/site1.las", []las.Point{
+		{X: 1, Y: 2, Z: 3, R: 65535},
+		{X: 4, Y: 5, Z: 6, G: 65535},
+	})
+
+	srv := NewServer(fsys, "output", 0)
+	req := httptest.NewRequest(http.MethodGet, "/points/site1.las.x3d", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<PointSet>") || !strings.Contains(body, "<Coordinate") {
+		t.Errorf("response isn't an X3D PointSet: %s", body)
+	}
+	if !strings.Contains(body, "1 2 3") || !strings.Contains(body, "4 5 6") {
+		t.Errorf("response missing expected point coordinates: %s", body)
+	}
+}
+
+func TestHandlePointsUnknownFile(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	srv := NewServer(fsys, "output", 0)
+	req := httptest.NewRequest(http.MethodGet, "/points/missing.las.x3d", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandlePointsFiltersByPassCount(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	w := las.NewWriterFS(fsys)
+	w.SetFormat(las.Version{Major: 1, Minor: 4}, 7)
+	w.SetExtraBytes(true)
+	w.AddPoint(las.Point{X: 1, Y: 1, Z: 1, Extra: &las.ExtraAttributes{PassCount: 1}})
+	w.AddPoint(las.Point{X: 2, Y: 2, Z: 2, Extra: &las.ExtraAttributes{PassCount: 5}})
+	if err := w.Write("output/site1.las"); err != nil {
+		t.Fatalf("writing test LAS: %v", err)
+	}
+
+	srv := NewServer(fsys, "output", 0)
+	req := httptest.NewRequest(http.MethodGet, "/points/site1.las.x3d?min_pass_count=3", nil)
+	resp := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(resp, req)
+
+	body := resp.Body.String()
+	if strings.Contains(body, "1 1 1") {
+		t.Errorf("expected point with PassCount 1 to be filtered out: %s", body)
+	}
+	if !strings.Contains(body, "2 2 2") {
+		t.Errorf("expected point with PassCount 5 to remain: %s", body)
+	}
+}
+
+func TestHandlePointsDecimatesLargeClouds(t *testing.T) {
+	fsys := fsutil.NewMemFilesystem()
+	var points []las.Point
+	for i := 0; i < 1000; i++ {
+		points = append(points, las.Point{X: float64(i), Y: float64(i), Z: float64(i)})
+	}
+	writeTestLAS(t, fsys, "output/big.las", points)
+
+	srv := NewServer(fsys, "output", 100)
+	req := httptest.NewRequest(http.MethodGet, "/points/big.las.x3d", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	count := strings.Count(w.Body.String(), "<Coordinate") // sanity: still one PointSet
+	if count != 1 {
+		t.Fatalf("expected exactly one Coordinate element, got %d", count)
+	}
+
+	_, rest, _ := strings.Cut(w.Body.String(), `<Coordinate point="`)
+	coords, _, _ := strings.Cut(rest, `"`)
+	if n := len(strings.Fields(coords)) / 3; n == 0 || n > 100 {
+		t.Errorf("rendered %d points, want between 1 and 100 after decimating 1000", n)
+	}
+}
@@ -0,0 +1,85 @@
+package previewserver
+
+import (
+	"math"
+
+	"compactmapper/las"
+)
+
+// voxelDecimate thins points down to roughly maxPoints by keeping one
+// representative point per voxel in a grid sized off points' bounding
+// box - the same coarse-grained tradeoff CloudCompare's own subsample
+// tool makes, rather than a random sample that can still thin dense
+// clusters unevenly. maxPoints <= 0 or len(points) <= maxPoints returns
+// points unchanged.
+func voxelDecimate(points []las.Point, maxPoints int) []las.Point {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	minX, minY, minZ := points[0].X, points[0].Y, points[0].Z
+	maxX, maxY, maxZ := points[0].X, points[0].Y, points[0].Z
+	for _, p := range points[1:] {
+		if p.X < minX {
+			minX = p.X
+		}
+		if p.Y < minY {
+			minY = p.Y
+		}
+		if p.Z < minZ {
+			minZ = p.Z
+		}
+		if p.X > maxX {
+			maxX = p.X
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
+		if p.Z > maxZ {
+			maxZ = p.Z
+		}
+	}
+
+	// Size each voxel so that, if points were spread evenly across the
+	// bounding box, there'd be roughly maxPoints of them. A degenerate
+	// (flat or single-point) bounding box has zero volume and can't be
+	// sized this way, so fall back to keeping every Nth point instead.
+	volume := (maxX - minX) * (maxY - minY) * (maxZ - minZ)
+	voxelSize := math.Cbrt(volume / float64(maxPoints))
+	if volume <= 0 || voxelSize <= 0 {
+		return strideDecimate(points, maxPoints)
+	}
+
+	type voxelKey struct{ x, y, z int64 }
+	seen := make(map[voxelKey]bool, maxPoints)
+	decimated := make([]las.Point, 0, maxPoints)
+	for _, p := range points {
+		key := voxelKey{
+			x: int64((p.X - minX) / voxelSize),
+			y: int64((p.Y - minY) / voxelSize),
+			z: int64((p.Z - minZ) / voxelSize),
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		decimated = append(decimated, p)
+	}
+	return decimated
+}
+
+// strideDecimate keeps every Nth point so the result has roughly
+// maxPoints points. It's voxelDecimate's fallback for a degenerate
+// bounding box (e.g. every point sharing one axis), where voxel sizing
+// by volume doesn't work.
+func strideDecimate(points []las.Point, maxPoints int) []las.Point {
+	stride := len(points) / maxPoints
+	if stride < 1 {
+		stride = 1
+	}
+	decimated := make([]las.Point, 0, maxPoints)
+	for i := 0; i < len(points); i += stride {
+		decimated = append(decimated, points[i])
+	}
+	return decimated
+}
@@ -0,0 +1,44 @@
+package previewserver
+
+import (
+	"fmt"
+	"strings"
+
+	"compactmapper/las"
+)
+
+// renderX3D builds a minimal X3D document containing one PointSet shape
+// for points, colored by each point's stored R/G/B (set at conversion
+// time by convertCSVtoLAS/converter.ColorMapper - the server doesn't
+// recompute color, it just reads back what's already on disk). X3DOM,
+// loaded by the index page, renders a Coordinate/Color pair as a GPU
+// point cloud with mouse-drag orbit built into its default "EXAMINE"
+// navigation mode, so no extra viewer JS is needed beyond the data
+// itself.
+func renderX3D(points []las.Point) string {
+	var coords, colors strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			coords.WriteByte(' ')
+			colors.WriteByte(' ')
+		}
+		fmt.Fprintf(&coords, "%g %g %g", p.X, p.Y, p.Z)
+		fmt.Fprintf(&colors, "%g %g %g", float64(p.R)/65535, float64(p.G)/65535, float64(p.B)/65535)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<X3D profile="Interchange" version="3.3">
+  <Scene>
+    <Shape>
+      <Appearance>
+        <PointProperties pointSizeScaleFactor="2"></PointProperties>
+      </Appearance>
+      <PointSet>
+        <Coordinate point="%s"></Coordinate>
+        <Color color="%s"></Color>
+      </PointSet>
+    </Shape>
+  </Scene>
+</X3D>
+`, coords.String(), colors.String())
+}
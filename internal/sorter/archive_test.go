@@ -0,0 +1,83 @@
+package sorter
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSortCSVToArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+2025/Oct/01 09:31:02.800,100,200,5.0,3,Design2,2.10,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := SortCSVToArchive(testCSV, &buf, false, nil); err != nil {
+		t.Fatalf("SortCSVToArchive failed: %v", err)
+	}
+
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading tar entry: %v", err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Format != tar.FormatPAX {
+			t.Errorf("entry %s: format = %v, want FormatPAX", hdr.Name, hdr.Format)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("error reading tar data for %s: %v", hdr.Name, err)
+		}
+		if len(data) == 0 {
+			t.Errorf("entry %s has no data", hdr.Name)
+		}
+	}
+
+	want := []string{"2025-10-01designDesign1amp097.csv", "2025-10-01designDesign2amp210.csv"}
+	if len(names) != len(want) {
+		t.Fatalf("archive has %d entries, want %d: %v", len(names), len(want), names)
+	}
+	for i, name := range want {
+		if names[i] != name {
+			t.Errorf("entry %d = %s, want %s", i, names[i], name)
+		}
+	}
+}
+
+func TestSortCSVToArchiveDeterministic(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second bytes.Buffer
+	if err := SortCSVToArchive(testCSV, &first, false, nil); err != nil {
+		t.Fatalf("first SortCSVToArchive failed: %v", err)
+	}
+	if err := SortCSVToArchive(testCSV, &second, false, nil); err != nil {
+		t.Fatalf("second SortCSVToArchive failed: %v", err)
+	}
+
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("expected two runs over the same input to produce identical archives")
+	}
+}
@@ -0,0 +1,209 @@
+package sorter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// defaultChecksumInterval is how many bytes of an output CSV a single
+// checksum sidecar entry covers by default.
+const defaultChecksumInterval = 64 * 1024
+
+// checksumSidecarMagic identifies a sidecar file as one of ours, so
+// Verify can fail fast on an unrelated or truncated .sum file instead of
+// misreading garbage as checksum entries.
+var checksumSidecarMagic = [4]byte{'C', 'M', 'S', 'C'}
+
+// crc32cTable is the Castagnoli CRC32 table: a standard, fast,
+// well-tested choice for "detect bit-rot in a block" checksumming. A
+// hand-rolled Murmur3 implementation was the other option the underlying
+// request suggested, but Murmur3 isn't in the standard library, and
+// reimplementing its finalizer mix purely to match nothing external (the
+// sidecar is only ever read back by our own Verify) would add risk for
+// no benefit over crc32.Castagnoli.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumSidecarPath returns the .sum sidecar path for an output CSV.
+func checksumSidecarPath(csvPath string) string {
+	return csvPath + ".sum"
+}
+
+// checksumWriter wraps the io.Writer an output CSV is written through,
+// mirroring every byte into a running CRC32C digest. Once `interval`
+// bytes have passed since the last digest, or on Close (for whatever
+// partial amount remains), it appends a sidecar entry recording that
+// span's byte length and checksum. Storing each entry's length (rather
+// than assuming every entry is exactly `interval` bytes, as a literal
+// fixed-interval scheme would) keeps sidecars correct even when a CSV is
+// appended to across more than one SortCSV call, since a later call's
+// first span won't generally align with where an earlier call's last,
+// possibly-short span left off.
+type checksumWriter struct {
+	w        io.Writer
+	sidecar  *os.File
+	interval int
+	buffered int
+	hash     uint32
+}
+
+// newChecksumWriter creates a checksumWriter writing CSV bytes to w and
+// appending digest entries to a sidecar file at checksumSidecarPath(csvPath).
+// If the sidecar doesn't exist yet, its header (magic + interval) is
+// written first.
+func newChecksumWriter(w io.Writer, csvPath string, interval int) (*checksumWriter, error) {
+	if interval <= 0 {
+		interval = defaultChecksumInterval
+	}
+
+	sidecarPath := checksumSidecarPath(csvPath)
+	writeHeader := true
+	if info, err := os.Stat(sidecarPath); err == nil && info.Size() > 0 {
+		writeHeader = false
+	}
+
+	sidecar, err := os.OpenFile(sidecarPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checksum sidecar %s: %v", sidecarPath, err)
+	}
+
+	if writeHeader {
+		header := make([]byte, 8)
+		copy(header[0:4], checksumSidecarMagic[:])
+		binary.BigEndian.PutUint32(header[4:8], uint32(interval))
+		if _, err := sidecar.Write(header); err != nil {
+			sidecar.Close()
+			return nil, fmt.Errorf("error writing checksum sidecar header: %v", err)
+		}
+	}
+
+	return &checksumWriter{w: w, sidecar: sidecar, interval: interval}, nil
+}
+
+// Write mirrors p into the wrapped writer and the running digest,
+// flushing a sidecar entry every time `interval` bytes accumulate.
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	if _, err := c.w.Write(p); err != nil {
+		return 0, err
+	}
+
+	for len(p) > 0 {
+		room := c.interval - c.buffered
+		n := room
+		if n > len(p) {
+			n = len(p)
+		}
+		c.hash = crc32.Update(c.hash, crc32cTable, p[:n])
+		c.buffered += n
+		p = p[n:]
+
+		if c.buffered == c.interval {
+			if err := c.flushEntry(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// flushEntry appends one (length, checksum) entry for whatever has
+// accumulated since the last entry, then resets for the next span.
+func (c *checksumWriter) flushEntry() error {
+	if c.buffered == 0 {
+		return nil
+	}
+	entry := make([]byte, 8)
+	binary.BigEndian.PutUint32(entry[0:4], uint32(c.buffered))
+	binary.BigEndian.PutUint32(entry[4:8], c.hash)
+	if _, err := c.sidecar.Write(entry); err != nil {
+		return fmt.Errorf("error writing checksum sidecar entry: %v", err)
+	}
+	c.buffered = 0
+	c.hash = 0
+	return nil
+}
+
+// Close flushes any final partial span and closes the sidecar file. It
+// does not close the wrapped CSV writer.
+func (c *checksumWriter) Close() error {
+	if err := c.flushEntry(); err != nil {
+		c.sidecar.Close()
+		return err
+	}
+	return c.sidecar.Close()
+}
+
+// CorruptRange is a byte range of a checksummed CSV whose sidecar
+// checksum didn't match the data actually on disk.
+type CorruptRange struct {
+	Start, Stop int64
+}
+
+// Verify re-reads path's checksum sidecar (path + ".sum", written when
+// SortOptions.Checksum was set) and reports every byte range whose
+// recorded checksum doesn't match the data currently in path. A nil,
+// empty result means the file matched its sidecar everywhere. Verify
+// keeps scanning past a mismatch instead of stopping at the first one,
+// so a single corrupted span doesn't hide others further in the file.
+func Verify(path string) ([]CorruptRange, error) {
+	sidecarPath := checksumSidecarPath(path)
+	sidecar, err := os.Open(sidecarPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checksum sidecar %s: %v", sidecarPath, err)
+	}
+	defer sidecar.Close()
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(sidecar, header); err != nil {
+		return nil, fmt.Errorf("error reading checksum sidecar header: %v", err)
+	}
+	if string(header[0:4]) != string(checksumSidecarMagic[:]) {
+		return nil, fmt.Errorf("%s is not a CompactMapper checksum sidecar", sidecarPath)
+	}
+
+	csvFile, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", path, err)
+	}
+	defer csvFile.Close()
+	csvReader := bufio.NewReader(csvFile)
+
+	var ranges []CorruptRange
+	var offset int64
+
+	entry := make([]byte, 8)
+	buf := make([]byte, defaultChecksumInterval)
+	for {
+		if _, err := io.ReadFull(sidecar, entry); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return ranges, fmt.Errorf("error reading checksum sidecar entry: %v", err)
+		}
+
+		length := binary.BigEndian.Uint32(entry[0:4])
+		wantHash := binary.BigEndian.Uint32(entry[4:8])
+
+		if cap(buf) < int(length) {
+			buf = make([]byte, length)
+		}
+		span := buf[:length]
+		if _, err := io.ReadFull(csvReader, span); err != nil {
+			return ranges, fmt.Errorf("error reading %s at offset %d: %v", path, offset, err)
+		}
+
+		gotHash := crc32.Checksum(span, crc32cTable)
+		if gotHash != wantHash {
+			ranges = append(ranges, CorruptRange{Start: offset, Stop: offset + int64(length)})
+		}
+
+		offset += int64(length)
+	}
+
+	return ranges, nil
+}
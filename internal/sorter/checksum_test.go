@@ -0,0 +1,84 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSortCSVWithOptionsChecksumVerify verifies an intact checksummed
+// output passes Verify, and that corrupting a byte in the CSV causes
+// Verify to report the range it falls in.
+func TestSortCSVWithOptionsChecksumVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+2025/Oct/01 09:22:49.600,101,201,5.1,2,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SortOptions{Checksum: true, ChecksumInterval: 16}
+	if err := SortCSVWithOptions(testCSV, outputDir, false, nil, opts); err != nil {
+		t.Fatalf("SortCSVWithOptions (checksum) failed: %v", err)
+	}
+
+	outputFile := filepath.Join(outputDir, "2025-10-01designDesign1amp097.csv")
+	if _, err := os.Stat(outputFile + ".sum"); err != nil {
+		t.Fatalf("expected .sum sidecar, got error: %v", err)
+	}
+
+	ranges, err := Verify(outputFile)
+	if err != nil {
+		t.Fatalf("Verify failed on intact file: %v", err)
+	}
+	if len(ranges) != 0 {
+		t.Errorf("Verify on intact file found corrupt ranges: %v", ranges)
+	}
+
+	// Corrupt a byte in the middle of the file and confirm Verify catches it.
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid := len(content) / 2
+	content[mid] ^= 0xFF
+	if err := os.WriteFile(outputFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ranges, err = Verify(outputFile)
+	if err != nil {
+		t.Fatalf("Verify failed on corrupted file: %v", err)
+	}
+	if len(ranges) == 0 {
+		t.Error("Verify on corrupted file found no corrupt ranges, want at least one")
+	}
+	for _, r := range ranges {
+		if int64(mid) < r.Start || int64(mid) >= r.Stop {
+			continue
+		}
+		return // found the range containing the corrupted byte
+	}
+	t.Errorf("corrupted byte offset %d not covered by any reported range: %v", mid, ranges)
+}
+
+// TestSortCSVWithOptionsChecksumAndStreamingRejected confirms the
+// unsupported Checksum+Streaming combination is rejected up front rather
+// than silently producing an incomplete sidecar.
+func TestSortCSVWithOptionsChecksumAndStreamingRejected(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	if err := os.WriteFile(testCSV, []byte("Time,DesignName,LastAmp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SortCSVWithOptions(testCSV, filepath.Join(tmpDir, "output"), false, nil, SortOptions{Streaming: true, Checksum: true})
+	if err == nil {
+		t.Error("expected error for Checksum+Streaming combination, got nil")
+	}
+}
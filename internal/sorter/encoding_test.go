@@ -0,0 +1,54 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSortCSVWithOptionsEncodingCP1252 verifies a cp1252-encoded input
+// (its DesignName column holds a curly apostrophe outside plain ASCII)
+// sorts correctly when SortOptions.Encoding names it.
+func TestSortCSVWithOptionsEncodingCP1252(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	// 0x92 is cp1252's right single quotation mark; invalid as UTF-8.
+	csvContent := []byte("Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n" +
+		"2025/Oct/01 09:30:02.800,100,200,5.0,2,Joe\x92s Lot,0.97,4\n")
+	if err := os.WriteFile(testCSV, csvContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SortOptions{Encoding: "cp1252"}
+	if err := SortCSVWithOptions(testCSV, outputDir, false, nil, opts); err != nil {
+		t.Fatalf("SortCSVWithOptions (cp1252) failed: %v", err)
+	}
+
+	outputFile := filepath.Join(outputDir, "2025-10-01designJoe’s Lotamp097.csv")
+	if _, err := os.Stat(outputFile); err != nil {
+		t.Fatalf("expected decoded output file, got error: %v", err)
+	}
+}
+
+// TestSortCSVWithOptionsEncodingUnsupported verifies an unvendored
+// encoding (Shift-JIS, requiring golang.org/x/text) surfaces a
+// descriptive error instead of mangling the input.
+func TestSortCSVWithOptionsEncodingUnsupported(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SortOptions{Encoding: "shift-jis"}
+	if err := SortCSVWithOptions(testCSV, outputDir, false, nil, opts); err == nil {
+		t.Fatal("expected an error for an unsupported encoding, got nil")
+	}
+}
@@ -0,0 +1,136 @@
+package sorter
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Row is the subset of a parsed telemetry row handed to an Exporter
+// alongside the group's CSV writer, carrying just the fields a trending
+// sink (see internal/exporter/rrd) cares about. Numeric fields are NaN
+// when the source cell was blank, "?" (CAT-roller's sentinel for "not
+// yet computed"), or otherwise unparseable - callers decide how their
+// sink represents a missing sample.
+type Row struct {
+	Time      time.Time
+	Machine   string
+	CMV       float64
+	MDP       float64
+	EVIB1     float64
+	PassCount float64
+	Speed     float64
+	Temp      float64
+}
+
+// Exporter receives every row SortCSV groups, alongside the group's CSV
+// output, so a row can be fanned out to another sink - such as a
+// Round-Robin Database, see internal/exporter/rrd - without sorter
+// needing to know anything about that sink's format. Export is called
+// once per row. SortCSV never calls Close: a caller that constructs an
+// Exporter owns its lifecycle and is responsible for closing it once
+// all sorting that uses it is finished.
+type Exporter interface {
+	Export(key GroupKey, row Row) error
+	Close() error
+}
+
+// exportColumns resolves Row's fields to a header's column indices once
+// per input file, rather than re-scanning the header for every row.
+// Indices are -1 for columns the header doesn't have, in which case
+// parse leaves the corresponding Row field at its zero value (or NaN,
+// for numeric fields).
+type exportColumns struct {
+	timeIdx      int
+	machineIdx   int
+	cmvIdx       int
+	mdpIdx       int
+	evib1Idx     int
+	passCountIdx int
+	speedIdx     int
+	tempIdx      int
+}
+
+// newExportColumns resolves header's column indices for Row's fields.
+// It maps Row's generic CMV/MDP/EVIB1/Temp names onto the CAT-roller
+// CSV schema's "Last"-prefixed equivalents (e.g. LastCMV), matching how
+// the rest of sorter already reads LastAmp for GroupKey.Amp.
+func newExportColumns(header []string) *exportColumns {
+	idx := func(name string) int {
+		for i, col := range header {
+			if col == name {
+				return i
+			}
+		}
+		return -1
+	}
+	return &exportColumns{
+		timeIdx:      idx("Time"),
+		machineIdx:   idx("Machine"),
+		cmvIdx:       idx("LastCMV"),
+		mdpIdx:       idx("LastMDP"),
+		evib1Idx:     idx("LastEVIB1"),
+		passCountIdx: idx("PassCount"),
+		speedIdx:     idx("Speed"),
+		tempIdx:      idx("LastTemp"),
+	}
+}
+
+// parse builds a Row from one CSV record, returning ok=false if the
+// record has no parseable Time - the one field every Exporter needs to
+// place a sample in its series.
+func (c *exportColumns) parse(row []string) (Row, bool) {
+	t, err := parseRowTime(field(row, c.timeIdx))
+	if err != nil {
+		return Row{}, false
+	}
+	return Row{
+		Time:      t,
+		Machine:   field(row, c.machineIdx),
+		CMV:       parseFloatOrNaN(field(row, c.cmvIdx)),
+		MDP:       parseFloatOrNaN(field(row, c.mdpIdx)),
+		EVIB1:     parseFloatOrNaN(field(row, c.evib1Idx)),
+		PassCount: parseFloatOrNaN(field(row, c.passCountIdx)),
+		Speed:     parseFloatOrNaN(field(row, c.speedIdx)),
+		Temp:      parseFloatOrNaN(field(row, c.tempIdx)),
+	}, true
+}
+
+// field returns row[idx], or "" if idx is out of range (including the
+// sentinel -1 used for a column the header didn't have).
+func field(row []string, idx int) string {
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return row[idx]
+}
+
+func parseRowTime(s string) (time.Time, error) {
+	return time.Parse("2006/Jan/02 15:04:05.999", s)
+}
+
+// parseFloatOrNaN parses s as a float64, returning NaN for a blank or
+// "?" cell and for any value that fails to parse outright, rather than
+// treating a malformed supplementary telemetry field as a fatal error -
+// unlike Time, DesignName, and LastAmp, these fields play no part in
+// SortCSV's grouping.
+func parseFloatOrNaN(s string) float64 {
+	if s == "" || s == "?" {
+		return math.NaN()
+	}
+	// Some of these columns carry a unit suffix (e.g. Speed: "0.8km/h");
+	// parse only the leading numeric run.
+	end := len(s)
+	for i, r := range s {
+		if r == '.' || r == '-' || r == '+' || (r >= '0' && r <= '9') {
+			continue
+		}
+		end = i
+		break
+	}
+	v, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return v
+}
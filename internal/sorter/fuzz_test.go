@@ -0,0 +1,110 @@
+//go:build fuzz
+
+package sorter
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// filenamePattern is the shape every SortCSV output file must match -
+// see generateFilename/sanitizeFilename. It relaxes the request's
+// \d+ amp suffix to \d* to account for normalizeAmp's "no_amp" case,
+// where generateFilename emits "...amp.csv" with no digits at all.
+var filenamePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}design[^/]+amp\d*\.csv$`)
+
+// FuzzSortCSV fuzzes SortCSV's CSV parsing with Go's native fuzzing,
+// seeded from TestEdgeCases/TestErrorHandling's hand-picked inputs (BOM,
+// "?" sentinels, special characters in DesignName, missing columns, bad
+// date formats). It asserts SortCSV never panics, that the sum of every
+// output file's row count equals the number of data rows groupCSVFile
+// itself parsed out of the same input (mirroring TestLargeDataChunking's
+// chunking invariant), and that every output filename matches
+// filenamePattern.
+//
+// Run with: go test -tags fuzz -fuzz FuzzSortCSV ./internal/sorter
+func FuzzSortCSV(f *testing.F) {
+	seeds := []string{
+		"\xEF\xBB\xBFTime,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n" +
+			"2025/Oct/01 09:00:00.000,1000.0,2000.0,10.0,1,Design1,0.97,4\n",
+		"Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n" +
+			"2025/Oct/01 09:00:00.000,1000.0,2000.0,10.0,1,Design_A-123,0.97,4\n" +
+			"2025/Oct/01 09:01:00.000,1001.0,2001.0,10.1,2,Design/B*456,1.50,4\n",
+		"Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n" +
+			"2025/Oct/01 09:00:00.000,1000.0,2000.0,10.0,1,Design1,?,4\n",
+		"Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n" +
+			"not-a-date,1000.0,2000.0,10.0,1,Design1,0.97,4\n",
+		"DesignName,LastAmp\nDesign1,0.97\n",
+		"",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, csvContent string) {
+		tmpDir := t.TempDir()
+		inputPath := filepath.Join(tmpDir, "fuzz.csv")
+		outputDir := filepath.Join(tmpDir, "out")
+
+		if err := os.WriteFile(inputPath, []byte(csvContent), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		_, groups, groupErr := groupCSVFile(inputPath, true, nil, "")
+
+		err := SortCSV(inputPath, outputDir, true, nil)
+		if err != nil {
+			if groupErr == nil {
+				t.Fatalf("SortCSV failed but groupCSVFile succeeded on the same input: %v", err)
+			}
+			return
+		}
+		if groupErr != nil {
+			t.Fatalf("SortCSV succeeded but groupCSVFile failed on the same input: %v", groupErr)
+		}
+
+		wantRows := 0
+		for _, rows := range groups {
+			wantRows += len(rows)
+		}
+
+		files, globErr := filepath.Glob(filepath.Join(outputDir, "*.csv"))
+		if globErr != nil {
+			t.Fatalf("Glob failed: %v", globErr)
+		}
+
+		totalRows := 0
+		for _, path := range files {
+			if !filenamePattern.MatchString(filepath.Base(path)) {
+				t.Errorf("output filename %q doesn't match the expected shape", filepath.Base(path))
+			}
+			rows, err := countDataRows(path)
+			if err != nil {
+				t.Fatalf("countDataRows(%s) failed: %v", path, err)
+			}
+			totalRows += rows
+		}
+
+		if totalRows != wantRows {
+			t.Errorf("sum of output row counts = %d, want %d (parsed data rows)", totalRows, wantRows)
+		}
+	})
+}
+
+// countDataRows counts path's CSV rows excluding the header.
+func countDataRows(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return 0, err
+	}
+	return len(records) - 1, nil
+}
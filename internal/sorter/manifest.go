@@ -0,0 +1,100 @@
+package sorter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// manifestFilename is the name of the per-output-directory manifest that
+// tracks each group file's date and row count, so the retention API
+// (TrimBefore, TrimByCount) can decide what to trim without re-scanning
+// every CSV in the directory.
+const manifestFilename = ".manifest.json"
+
+// ManifestEntry is one group file's tracked state.
+type ManifestEntry struct {
+	// Date is the group's Date (e.g. "2025-10-01"), shared by every row
+	// in the file since a GroupKey - and so its output file - never mixes
+	// rows from more than one date.
+	Date string `json:"date"`
+	// RowCount is the number of data rows (excluding the header)
+	// currently written to the group's file.
+	RowCount int `json:"row_count"`
+}
+
+// manifest is the in-memory form of manifestFilename, keyed by the
+// group's sanitized output filename.
+type manifest struct {
+	Entries map[string]ManifestEntry `json:"entries"`
+}
+
+func manifestPath(outputDir string) string {
+	return filepath.Join(outputDir, manifestFilename)
+}
+
+// loadManifest reads outputDir's manifest, returning an empty one if it
+// doesn't exist yet.
+func loadManifest(outputDir string) (*manifest, error) {
+	data, err := os.ReadFile(manifestPath(outputDir))
+	if os.IsNotExist(err) {
+		return &manifest{Entries: make(map[string]ManifestEntry)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %v", err)
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]ManifestEntry)
+	}
+	return &m, nil
+}
+
+// save writes m back to outputDir's manifest file.
+func (m *manifest) save(outputDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %v", err)
+	}
+	if err := os.WriteFile(manifestPath(outputDir), data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	return nil
+}
+
+// manifestLockName is a reserved key into fileLockRegistry used to
+// serialize manifest read-modify-write cycles across SortCSVDirectoryWithOptions's
+// worker pool, the same way real group filenames serialize output writes.
+// It can't collide with a real group filename since sanitizeFilename
+// never produces a name starting with ".".
+const manifestLockName = ".manifest.json"
+
+// recordManifestEntry updates outputDir's manifest to reflect rowsAdded
+// more rows having been appended to filename (whose rows are all dated
+// date), creating the entry if this is the group's first write. If
+// locks is non-nil, the read-modify-write is serialized the same way
+// group file writes are.
+func recordManifestEntry(outputDir, filename, date string, rowsAdded int, locks *fileLockRegistry) error {
+	if locks != nil {
+		mu := locks.lock(manifestLockName)
+		mu.Lock()
+		defer mu.Unlock()
+	}
+
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		return err
+	}
+
+	entry := m.Entries[filename]
+	entry.Date = date
+	entry.RowCount += rowsAdded
+	m.Entries[filename] = entry
+
+	return m.save(outputDir)
+}
@@ -0,0 +1,162 @@
+package sorter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// SortDirectoryOptions configures SortCSVDirectoryWithOptions.
+type SortDirectoryOptions struct {
+	// Concurrency is how many input files are sorted in parallel. Zero or
+	// negative selects runtime.NumCPU().
+	Concurrency int
+
+	// Exporter, if non-nil, receives every row alongside its group's CSV
+	// output, same as SortOptions.Exporter. Since it's shared across the
+	// worker pool, an Exporter implementation must be safe to call from
+	// multiple goroutines concurrently, including two workers exporting
+	// to the same group (e.g. the same Date+DesignName+LastAmp appears
+	// in two different input files).
+	Exporter Exporter
+
+	// Encoding is passed straight through to each file's SortOptions.Encoding.
+	// Every file in the directory is assumed to share one encoding; mixed
+	// encodings need per-file SortCSVWithOptions calls instead.
+	Encoding string
+
+	// Progress, if non-nil, receives a FileResult as each file finishes so
+	// callers (e.g. the GUI) can report live per-file progress instead of
+	// waiting for the whole batch - mirrors converter.ConvertOptions.Progress.
+	Progress chan<- FileResult
+}
+
+// FileResult records the outcome of sorting a single CSV file.
+type FileResult struct {
+	Path string
+	Err  error
+}
+
+// SortCSVDirectoryWithOptions processes all CSV files in inputDir as
+// SortCSVDirectory does, but fans them out across a worker pool sized by
+// opts.Concurrency. Two workers can legitimately append to the same
+// output file - e.g. two different days' exports both contain rows for
+// the same Date+DesignName+LastAmp group - so output file writes are
+// guarded by a mutex keyed by the output file's sanitized name, and
+// errorLog writes (which would otherwise come from multiple goroutines
+// at once) are serialized through a single shared, mutex-guarded writer.
+// opts.Progress, if set, receives one FileResult as each file finishes,
+// whether it succeeded or not.
+func SortCSVDirectoryWithOptions(inputDir, outputDir string, skipErrors bool, errorLog *os.File, opts SortDirectoryOptions) error {
+	files, err := filepath.Glob(filepath.Join(inputDir, "*.csv"))
+	if err != nil {
+		return fmt.Errorf("error scanning directory: %v", err)
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no CSV files found in %s", inputDir)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency > len(files) {
+		concurrency = len(files)
+	}
+
+	var sharedLog io.Writer
+	if errorLog != nil {
+		sharedLog = &syncWriter{w: errorLog}
+	}
+	locks := newFileLockRegistry()
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	worker := func() {
+		defer wg.Done()
+		for csvFile := range jobs {
+			err := sortCSVInMemoryLocked(csvFile, outputDir, skipErrors, sharedLog, locks, SortOptions{Exporter: opts.Exporter, Encoding: opts.Encoding})
+			if err != nil {
+				if skipErrors {
+					if sharedLog != nil {
+						fmt.Fprintf(sharedLog, "Error processing %s: %v\n", filepath.Base(csvFile), err)
+					}
+				} else {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("error processing %s: %v", filepath.Base(csvFile), err)
+					}
+					mu.Unlock()
+				}
+			}
+
+			if opts.Progress != nil {
+				opts.Progress <- FileResult{Path: csvFile, Err: err}
+			}
+		}
+	}
+
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go worker()
+	}
+
+feed:
+	for _, file := range files {
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop {
+			break feed
+		}
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+// syncWriter guards Write with a mutex so multiple goroutines can safely
+// share one underlying io.Writer (e.g. an error log file) without
+// interleaving partial writes from concurrent Fprintf calls.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// fileLockRegistry hands out a per-name *sync.Mutex, creating it on
+// first use. It's used to serialize writes from different workers that
+// target the same output file.
+type fileLockRegistry struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newFileLockRegistry() *fileLockRegistry {
+	return &fileLockRegistry{locks: make(map[string]*sync.Mutex)}
+}
+
+func (r *fileLockRegistry) lock(name string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mu, ok := r.locks[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		r.locks[name] = mu
+	}
+	return mu
+}
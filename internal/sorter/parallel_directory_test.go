@@ -0,0 +1,105 @@
+package sorter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSortCSVDirectoryWithOptionsConcurrent verifies the worker pool
+// produces the same grouped output as the sequential SortCSVDirectory,
+// including correctly merging rows from two different input files that
+// land in the same output group.
+func TestSortCSVDirectoryWithOptionsConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	header := "Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n"
+	// Two separate input files whose rows both belong to the same
+	// output group (2025-10-01, Design1, amp 0.97), to exercise the
+	// cross-worker append-lock path.
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(inputDir, fmt.Sprintf("day%d.csv", i))
+		content := header + "2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := SortCSVDirectoryWithOptions(inputDir, outputDir, false, nil, SortDirectoryOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("SortCSVDirectoryWithOptions failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "2025-10-01designDesign1amp097.csv"))
+	if err != nil {
+		t.Fatalf("expected merged output file, got error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 { // header + 2 rows (one from each input file)
+		t.Errorf("expected 3 lines (header + 2 rows), got %d:\n%s", len(lines), content)
+	}
+}
+
+// TestSortCSVDirectoryWithOptionsProgress verifies opts.Progress receives
+// exactly one FileResult per input file.
+func TestSortCSVDirectoryWithOptionsProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	outputDir := filepath.Join(tmpDir, "output")
+
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	header := "Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount\n"
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(inputDir, fmt.Sprintf("day%d.csv", i))
+		content := header + "2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	progressCh := make(chan FileResult, 3)
+	err := SortCSVDirectoryWithOptions(inputDir, outputDir, false, nil, SortDirectoryOptions{Concurrency: 2, Progress: progressCh})
+	close(progressCh)
+	if err != nil {
+		t.Fatalf("SortCSVDirectoryWithOptions failed: %v", err)
+	}
+
+	var results []FileResult
+	for r := range progressCh {
+		results = append(results, r)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d progress results, want 3", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("unexpected per-file error for %s: %v", r.Path, r.Err)
+		}
+	}
+}
+
+// TestSortCSVDirectoryWithOptionsEmptyDirectory confirms an empty
+// input directory surfaces an error.
+func TestSortCSVDirectoryWithOptionsEmptyDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "empty")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SortCSVDirectoryWithOptions(inputDir, filepath.Join(tmpDir, "output"), false, nil, SortDirectoryOptions{})
+	if err == nil {
+		t.Error("expected error for directory with no CSV files, got nil")
+	}
+}
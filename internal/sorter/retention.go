@@ -0,0 +1,180 @@
+package sorter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// archivedSuffix is appended to a group file (and its checksum sidecar,
+// if present) when TrimBefore retires it, rather than deleting it
+// outright - archived data can still be recovered by hand if a retention
+// cutoff turns out to have been too aggressive.
+const archivedSuffix = ".archived"
+
+// TrimBefore retires every group file in outputDir whose manifest Date
+// is strictly before cutoff, renaming it (and its .sum sidecar, if any)
+// with archivedSuffix rather than deleting it. It returns the number of
+// files archived. Using the manifest instead of re-parsing every CSV
+// keeps this O(groups) rather than O(rows).
+func TrimBefore(outputDir string, cutoff time.Time) (removed int, err error) {
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		return 0, err
+	}
+
+	for filename, entry := range m.Entries {
+		date, err := time.Parse("2006-01-02", entry.Date)
+		if err != nil {
+			return removed, fmt.Errorf("error parsing manifest date %q for %s: %v", entry.Date, filename, err)
+		}
+		if !date.Before(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(outputDir, filename)
+		if err := os.Rename(path, path+archivedSuffix); err != nil {
+			if os.IsNotExist(err) {
+				// Already removed by hand; still drop it from the manifest.
+				delete(m.Entries, filename)
+				continue
+			}
+			return removed, fmt.Errorf("error archiving %s: %v", filename, err)
+		}
+
+		sidecarPath := checksumSidecarPath(path)
+		if _, err := os.Stat(sidecarPath); err == nil {
+			if err := os.Rename(sidecarPath, sidecarPath+archivedSuffix); err != nil {
+				return removed, fmt.Errorf("error archiving checksum sidecar for %s: %v", filename, err)
+			}
+		}
+
+		delete(m.Entries, filename)
+		removed++
+	}
+
+	if removed > 0 {
+		if err := m.save(outputDir); err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
+
+// TrimByCount rewrites every group file with more than maxRowsPerGroup
+// rows, dropping the rows with the oldest Time values until the file is
+// at the cap. It returns the number of files rewritten. Any checksum
+// sidecar for a rewritten file is removed, since its digests no longer
+// match the rewritten content.
+func TrimByCount(outputDir string, maxRowsPerGroup int) (trimmed int, err error) {
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		return 0, err
+	}
+
+	for filename, entry := range m.Entries {
+		if entry.RowCount <= maxRowsPerGroup {
+			continue
+		}
+
+		path := filepath.Join(outputDir, filename)
+		newCount, err := trimOldestRows(path, maxRowsPerGroup)
+		if err != nil {
+			return trimmed, fmt.Errorf("error trimming %s: %v", filename, err)
+		}
+
+		entry.RowCount = newCount
+		m.Entries[filename] = entry
+		trimmed++
+
+		sidecarPath := checksumSidecarPath(path)
+		if err := os.Remove(sidecarPath); err != nil && !os.IsNotExist(err) {
+			return trimmed, fmt.Errorf("error removing stale checksum sidecar for %s: %v", filename, err)
+		}
+	}
+
+	if trimmed > 0 {
+		if err := m.save(outputDir); err != nil {
+			return trimmed, err
+		}
+	}
+
+	return trimmed, nil
+}
+
+// trimOldestRows reads path, keeps only its maxRows most recent rows (by
+// the Time column), and rewrites the file with those rows in ascending
+// time order. It returns the row count actually kept (== maxRows, unless
+// the file had fewer rows than expected).
+func trimOldestRows(path string, maxRows int) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening file: %v", err)
+	}
+	reader := csv.NewReader(f)
+
+	header, err := reader.Read()
+	if err != nil {
+		f.Close()
+		return 0, fmt.Errorf("error reading header: %v", err)
+	}
+
+	timeIdx := -1
+	for i, col := range header {
+		if col == "Time" {
+			timeIdx = i
+			break
+		}
+	}
+	if timeIdx < 0 {
+		f.Close()
+		return 0, fmt.Errorf("missing required column: Time")
+	}
+
+	rows, err := reader.ReadAll()
+	f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("error reading rows: %v", err)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		ti, errI := time.Parse("2006/Jan/02 15:04:05.999", rows[i][timeIdx])
+		tj, errJ := time.Parse("2006/Jan/02 15:04:05.999", rows[j][timeIdx])
+		if errI != nil || errJ != nil {
+			// Unparseable timestamps sort last, rather than panicking or
+			// silently dropping the row.
+			return errI == nil
+		}
+		return ti.Before(tj)
+	})
+
+	if len(rows) > maxRows {
+		rows = rows[len(rows)-maxRows:]
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("error rewriting file: %v", err)
+	}
+	defer out.Close()
+
+	writer := csv.NewWriter(out)
+	if err := writer.Write(header); err != nil {
+		return 0, fmt.Errorf("error writing header: %v", err)
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return 0, fmt.Errorf("error writing row: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, fmt.Errorf("error flushing writer: %v", err)
+	}
+
+	return len(rows), nil
+}
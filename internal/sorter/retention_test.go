@@ -0,0 +1,158 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSortedTestDir(t *testing.T, tmpDir string) string {
+	t.Helper()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	old := filepath.Join(tmpDir, "old.csv")
+	oldContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Sep/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+`
+	if err := os.WriteFile(old, []byte(oldContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recent := filepath.Join(tmpDir, "recent.csv")
+	recentContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/15 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+`
+	if err := os.WriteFile(recent, []byte(recentContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SortCSV(old, outputDir, false, nil); err != nil {
+		t.Fatalf("SortCSV(old) failed: %v", err)
+	}
+	if err := SortCSV(recent, outputDir, false, nil); err != nil {
+		t.Fatalf("SortCSV(recent) failed: %v", err)
+	}
+
+	return outputDir
+}
+
+// TestSortCSVWritesManifest confirms SortCSV keeps the manifest in sync
+// with what it writes, including accumulating row counts across appends.
+func TestSortCSVWritesManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := writeSortedTestDir(t, tmpDir)
+
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+
+	oldEntry, ok := m.Entries["2025-09-01designDesign1amp097.csv"]
+	if !ok {
+		t.Fatal("expected manifest entry for old group")
+	}
+	if oldEntry.Date != "2025-09-01" || oldEntry.RowCount != 1 {
+		t.Errorf("old entry = %+v, want Date=2025-09-01 RowCount=1", oldEntry)
+	}
+
+	recentEntry, ok := m.Entries["2025-10-15designDesign1amp097.csv"]
+	if !ok {
+		t.Fatal("expected manifest entry for recent group")
+	}
+	if recentEntry.Date != "2025-10-15" || recentEntry.RowCount != 1 {
+		t.Errorf("recent entry = %+v, want Date=2025-10-15 RowCount=1", recentEntry)
+	}
+}
+
+// TestTrimBefore verifies files older than cutoff are archived (renamed,
+// not deleted) and dropped from the manifest, while newer files are left
+// alone.
+func TestTrimBefore(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := writeSortedTestDir(t, tmpDir)
+
+	cutoff, _ := time.Parse("2006-01-02", "2025-10-01")
+	removed, err := TrimBefore(outputDir, cutoff)
+	if err != nil {
+		t.Fatalf("TrimBefore failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+
+	oldPath := filepath.Join(outputDir, "2025-09-01designDesign1amp097.csv")
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Error("expected old group file to be renamed away, but it's still present")
+	}
+	if _, err := os.Stat(oldPath + archivedSuffix); err != nil {
+		t.Errorf("expected archived file to exist: %v", err)
+	}
+
+	recentPath := filepath.Join(outputDir, "2025-10-15designDesign1amp097.csv")
+	if _, err := os.Stat(recentPath); err != nil {
+		t.Errorf("expected recent group file to remain: %v", err)
+	}
+
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+	if _, ok := m.Entries["2025-09-01designDesign1amp097.csv"]; ok {
+		t.Error("expected archived entry to be removed from manifest")
+	}
+	if _, ok := m.Entries["2025-10-15designDesign1amp097.csv"]; !ok {
+		t.Error("expected recent entry to remain in manifest")
+	}
+}
+
+// TestTrimByCount verifies a group file with more rows than the cap is
+// rewritten to keep only its most recent rows.
+func TestTrimByCount(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:00:00.000,100,200,5.0,2,Design1,0.97,4
+2025/Oct/01 10:00:00.000,101,201,5.1,2,Design1,0.97,4
+2025/Oct/01 11:00:00.000,102,202,5.2,2,Design1,0.97,4
+2025/Oct/01 12:00:00.000,103,203,5.3,2,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SortCSV(testCSV, outputDir, false, nil); err != nil {
+		t.Fatalf("SortCSV failed: %v", err)
+	}
+
+	trimmed, err := TrimByCount(outputDir, 2)
+	if err != nil {
+		t.Fatalf("TrimByCount failed: %v", err)
+	}
+	if trimmed != 1 {
+		t.Errorf("trimmed = %d, want 1", trimmed)
+	}
+
+	outputFile := filepath.Join(outputDir, "2025-10-01designDesign1amp097.csv")
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 { // header + 2 kept rows
+		t.Fatalf("expected 3 lines (header + 2 kept rows), got %d:\n%s", len(lines), content)
+	}
+	if !strings.Contains(lines[1], "11:00:00") || !strings.Contains(lines[2], "12:00:00") {
+		t.Errorf("expected the two most recent rows to be kept, got:\n%s", content)
+	}
+
+	m, err := loadManifest(outputDir)
+	if err != nil {
+		t.Fatalf("loadManifest failed: %v", err)
+	}
+	if entry := m.Entries["2025-10-01designDesign1amp097.csv"]; entry.RowCount != 2 {
+		t.Errorf("manifest RowCount = %d, want 2", entry.RowCount)
+	}
+}
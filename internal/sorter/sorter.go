@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"compactmapper/internal/textenc"
 )
 
 const (
@@ -51,6 +53,18 @@ func normalizeAmp(amp string) string {
 	return normalized
 }
 
+// normalizeDesign gives an empty or missing DesignName a placeholder,
+// the same way normalizeAmp does for amplitude - otherwise
+// generateFilename would produce "{date}designamp{amp}.csv" with
+// nothing between "design" and "amp", and every row with an empty
+// DesignName for a given date/amp would collide onto that one file.
+func normalizeDesign(design string) string {
+	if design == "" || design == "?" {
+		return "no_design"
+	}
+	return design
+}
+
 // generateFilename creates filename from date, design, and amp
 func generateFilename(date, design, amp string) string {
 	// Format: {date}design{design}amp{amp}.csv
@@ -72,34 +86,101 @@ type GroupKey struct {
 // skipErrors: when true, rows with parsing errors are skipped and logged instead of stopping execution
 // errorLog: optional file handle for logging errors (required when skipErrors is true)
 // This allows processing to continue even when source CSV files contain corrupt or malformed data
+//
+// SortCSV accumulates every row in memory before writing any output, which
+// breaks down on multi-GB inputs. SortCSVWithOptions with
+// SortOptions.Streaming set writes each row to its group file as it's
+// parsed instead, though it still reads the whole input file into memory
+// first (see SortOptions.Streaming).
 func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) error {
-	// Open input file
-	file, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("error opening file: %v", err)
+	return SortCSVWithOptions(inputPath, outputDir, skipErrors, errorLog, SortOptions{})
+}
+
+// SortCSVWithOptions processes a single CSV file as SortCSV does, but lets
+// the caller select a processing strategy via opts.
+func SortCSVWithOptions(inputPath, outputDir string, skipErrors bool, errorLog *os.File, opts SortOptions) error {
+	if opts.Streaming {
+		if opts.Checksum {
+			return fmt.Errorf("SortOptions.Checksum is not supported together with Streaming")
+		}
+		if opts.Exporter != nil {
+			return fmt.Errorf("SortOptions.Exporter is not supported together with Streaming")
+		}
+		return sortCSVStreaming(inputPath, outputDir, skipErrors, errorLog, opts)
 	}
-	defer file.Close()
 
+	var w io.Writer
+	if errorLog != nil {
+		w = errorLog
+	}
+	return sortCSVInMemoryLocked(inputPath, outputDir, skipErrors, w, nil, opts)
+}
+
+// sortCSVInMemory is SortCSV's original implementation: it groups every
+// row into an in-memory map before writing any output file.
+func sortCSVInMemory(inputPath, outputDir string, skipErrors bool, errorLog *os.File) error {
+	// errorLog is only wrapped as an io.Writer when non-nil: passing a nil
+	// *os.File through an io.Writer parameter directly would produce a
+	// non-nil interface wrapping a nil pointer, which would then fail the
+	// "errorLog != nil" checks inside sortCSVInMemoryLocked.
+	var w io.Writer
+	if errorLog != nil {
+		w = errorLog
+	}
+	return sortCSVInMemoryLocked(inputPath, outputDir, skipErrors, w, nil, SortOptions{})
+}
+
+// sortCSVInMemoryLocked is sortCSVInMemory generalized for
+// SortCSVWithOptions's Checksum support and SortCSVDirectoryWithOptions's
+// worker pool: errorLog is an io.Writer so callers can pass a
+// mutex-guarded writer shared across workers, locks, when non-nil, is
+// consulted before each group's output file is opened so two workers
+// appending to the same {date}design{d}amp{a}.csv don't interleave rows,
+// and opts carries the Checksum settings applied to each group's output.
+func sortCSVInMemoryLocked(inputPath, outputDir string, skipErrors bool, errorLog io.Writer, locks *fileLockRegistry, opts SortOptions) error {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("error creating output directory: %v", err)
 	}
 
-	// Read all content to handle BOM
+	header, groups, err := groupCSVFile(inputPath, skipErrors, errorLog, opts.Encoding)
+	if err != nil {
+		return err
+	}
+
+	return writeGroups(outputDir, header, groups, locks, opts)
+}
+
+// groupCSVFile reads inputPath and groups its rows by GroupKey. It's the
+// shared first stage behind sortCSVInMemoryLocked (which then writes
+// each group to a loose output file) and SortCSVToArchive (which instead
+// writes each group as one tar entry). encoding is passed straight to
+// textenc.Decode; see SortOptions.Encoding.
+func groupCSVFile(inputPath string, skipErrors bool, errorLog io.Writer, encoding string) ([]string, map[GroupKey][][]string, error) {
+	// Open input file
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	// Read all content to decode its encoding
 	content, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf("error reading file: %v", err)
+		return nil, nil, fmt.Errorf("error reading file: %v", err)
 	}
 
-	// Remove UTF-8 BOM if present (EF BB BF)
-	content = bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})
+	content, err = textenc.Decode(content, encoding)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	reader := csv.NewReader(bytes.NewReader(content))
 
 	// Read header
 	header, err := reader.Read()
 	if err != nil {
-		return fmt.Errorf("error reading header: %v", err)
+		return nil, nil, fmt.Errorf("error reading header: %v", err)
 	}
 
 	// Find column indices
@@ -112,7 +193,7 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 	required := []string{"Time", "DesignName", "LastAmp"}
 	for _, col := range required {
 		if _, ok := colMap[col]; !ok {
-			return fmt.Errorf("missing required column: %s", col)
+			return nil, nil, fmt.Errorf("missing required column: %s", col)
 		}
 	}
 
@@ -124,6 +205,7 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 	groups := make(map[GroupKey][][]string)
 	chunk := make([][]string, 0, ChunkSize)
 	rowCount := 0
+	baseName := filepath.Base(inputPath)
 
 	// Track skipped rows to provide feedback on data quality issues
 	skippedRows := 0
@@ -132,10 +214,10 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 		if err == io.EOF {
 			// Process last chunk
 			if len(chunk) > 0 {
-				skipped, err := processChunk(chunk, timeIdx, designIdx, ampIdx, groups, skipErrors, errorLog, filepath.Base(inputPath))
+				skipped, err := processChunk(chunk, timeIdx, designIdx, ampIdx, groups, skipErrors, errorLog, baseName)
 				skippedRows += skipped
 				if err != nil && !skipErrors {
-					return err
+					return nil, nil, err
 				}
 			}
 			break
@@ -145,12 +227,12 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 			// This prevents a single malformed row from stopping the entire pipeline
 			if skipErrors {
 				if errorLog != nil {
-					fmt.Fprintf(errorLog, "File: %s, Row %d: error reading row: %v\n", filepath.Base(inputPath), rowCount+2, err)
+					fmt.Fprintf(errorLog, "File: %s, Row %d: error reading row: %v\n", baseName, rowCount+2, err)
 				}
 				skippedRows++
 				continue
 			}
-			return fmt.Errorf("error reading row %d: %v", rowCount+2, err)
+			return nil, nil, fmt.Errorf("error reading row %d: %v", rowCount+2, err)
 		}
 
 		chunk = append(chunk, record)
@@ -158,61 +240,115 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 
 		// Process chunk when it reaches ChunkSize
 		if len(chunk) >= ChunkSize {
-			skipped, err := processChunk(chunk, timeIdx, designIdx, ampIdx, groups, skipErrors, errorLog, filepath.Base(inputPath))
+			skipped, err := processChunk(chunk, timeIdx, designIdx, ampIdx, groups, skipErrors, errorLog, baseName)
 			skippedRows += skipped
 			if err != nil && !skipErrors {
-				return err
+				return nil, nil, err
 			}
 			chunk = make([][]string, 0, ChunkSize)
 		}
 	}
 
 	if skipErrors && skippedRows > 0 && errorLog != nil {
-		fmt.Fprintf(errorLog, "File: %s - Total skipped rows during sorting: %d\n", filepath.Base(inputPath), skippedRows)
+		fmt.Fprintf(errorLog, "File: %s - Total skipped rows during sorting: %d\n", baseName, skippedRows)
+	}
+
+	return header, groups, nil
+}
+
+// writeGroups writes every group's rows to its generateFilename output
+// file in outputDir, appending to the file if it already exists (e.g.
+// from a prior chunk or a previous input file). If locks is non-nil,
+// each output file's read-modify-write is guarded by a mutex keyed by
+// its sanitized filename, so SortCSVDirectoryWithOptions's worker pool
+// can safely have two input files append to the same group file.
+func writeGroups(outputDir string, header []string, groups map[GroupKey][][]string, locks *fileLockRegistry, opts SortOptions) error {
+	var cols *exportColumns
+	if opts.Exporter != nil {
+		cols = newExportColumns(header)
 	}
 
-	// Write grouped data to files
 	for key, rows := range groups {
 		filename := generateFilename(key.Date, key.DesignName, key.Amp)
 		sanitized := sanitizeFilename(filename)
-		outputPath := filepath.Join(outputDir, sanitized)
 
-		// Check if file exists (append mode for chunked processing)
-		fileExists := false
-		if _, err := os.Stat(outputPath); err == nil {
-			fileExists = true
+		writeOne := func() error {
+			if locks != nil {
+				mu := locks.lock(sanitized)
+				mu.Lock()
+				defer mu.Unlock()
+			}
+			return writeGroupFile(filepath.Join(outputDir, sanitized), sanitized, header, rows, opts)
 		}
-
-		outFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			return fmt.Errorf("error creating output file %s: %v", sanitized, err)
+		if err := writeOne(); err != nil {
+			return err
 		}
 
-		writer := csv.NewWriter(outFile)
+		if err := recordManifestEntry(outputDir, sanitized, key.Date, len(rows), locks); err != nil {
+			return err
+		}
 
-		// Write header only if file is new
-		if !fileExists {
-			if err := writer.Write(header); err != nil {
-				outFile.Close()
-				return fmt.Errorf("error writing header to %s: %v", sanitized, err)
+		if opts.Exporter != nil {
+			for _, row := range rows {
+				exportRow, ok := cols.parse(row)
+				if !ok {
+					continue
+				}
+				if err := opts.Exporter.Export(key, exportRow); err != nil {
+					return fmt.Errorf("error exporting row for group %v: %v", key, err)
+				}
 			}
 		}
+	}
 
-		// Write data rows
-		for _, row := range rows {
-			if err := writer.Write(row); err != nil {
-				outFile.Close()
-				return fmt.Errorf("error writing row to %s: %v", sanitized, err)
-			}
+	return nil
+}
+
+// writeGroupFile appends rows (and header, if the file doesn't already
+// exist) to outputPath. When opts.Checksum is set, the appended bytes are
+// also mirrored into a .sum sidecar via checksumWriter.
+func writeGroupFile(outputPath, sanitized string, header []string, rows [][]string, opts SortOptions) error {
+	// Check if file exists (append mode for chunked processing)
+	fileExists := false
+	if _, err := os.Stat(outputPath); err == nil {
+		fileExists = true
+	}
+
+	outFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error creating output file %s: %v", sanitized, err)
+	}
+	defer outFile.Close()
+
+	var dest io.Writer = outFile
+	if opts.Checksum {
+		cw, err := newChecksumWriter(outFile, outputPath, opts.ChecksumInterval)
+		if err != nil {
+			return err
 		}
+		defer cw.Close()
+		dest = cw
+	}
 
-		writer.Flush()
-		if err := writer.Error(); err != nil {
-			outFile.Close()
-			return fmt.Errorf("error flushing writer for %s: %v", sanitized, err)
+	writer := csv.NewWriter(dest)
+
+	// Write header only if file is new
+	if !fileExists {
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("error writing header to %s: %v", sanitized, err)
 		}
+	}
+
+	// Write data rows
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing row to %s: %v", sanitized, err)
+		}
+	}
 
-		outFile.Close()
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error flushing writer for %s: %v", sanitized, err)
 	}
 
 	return nil
@@ -221,7 +357,7 @@ func SortCSV(inputPath, outputDir string, skipErrors bool, errorLog *os.File) er
 // processChunk processes a chunk of rows and groups them
 // Returns the number of skipped rows and any fatal error
 // When skipErrors is true, parsing errors are logged and the row is skipped
-func processChunk(chunk [][]string, timeIdx, designIdx, ampIdx int, groups map[GroupKey][][]string, skipErrors bool, errorLog *os.File, filename string) (int, error) {
+func processChunk(chunk [][]string, timeIdx, designIdx, ampIdx int, groups map[GroupKey][][]string, skipErrors bool, errorLog io.Writer, filename string) (int, error) {
 	skippedRows := 0
 	for i, row := range chunk {
 		// Parse date from Time column
@@ -237,7 +373,7 @@ func processChunk(chunk [][]string, timeIdx, designIdx, ampIdx int, groups map[G
 			return skippedRows, fmt.Errorf("error parsing date from '%s': %v", row[timeIdx], err)
 		}
 
-		design := row[designIdx]
+		design := normalizeDesign(row[designIdx])
 		amp := normalizeAmp(row[ampIdx])
 
 		key := GroupKey{
@@ -251,9 +387,12 @@ func processChunk(chunk [][]string, timeIdx, designIdx, ampIdx int, groups map[G
 	return skippedRows, nil
 }
 
-// SortCSVDirectory processes all CSV files in a directory
+// SortCSVDirectory processes all CSV files in a directory sequentially.
 // skipErrors: when true, files with errors are logged and processing continues with remaining files
 // errorLog: optional file handle for logging errors (required when skipErrors is true)
+//
+// For directories with many files, SortCSVDirectoryWithOptions can fan
+// the work out across a worker pool instead.
 func SortCSVDirectory(inputDir, outputDir string, skipErrors bool, errorLog *os.File) error {
 	// Find all CSV files in input directory
 	files, err := filepath.Glob(filepath.Join(inputDir, "*.csv"))
@@ -0,0 +1,370 @@
+package sorter
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"compactmapper/internal/textenc"
+)
+
+// defaultMaxOpenFiles bounds how many group output files streaming mode
+// keeps open at once when SortOptions.MaxOpenFiles isn't set.
+const defaultMaxOpenFiles = 256
+
+// SortOptions configures how SortCSVWithOptions processes a file.
+type SortOptions struct {
+	// Streaming, when true, writes each row to its group's output file as
+	// soon as it's parsed instead of accumulating every grouped row in
+	// memory before writing any output, at the cost of holding file
+	// descriptors open while a group is being written to. The input file
+	// itself is still read and decoded into memory in full before parsing
+	// starts, so peak memory grows with the input file's size rather than
+	// staying bounded by the number of open groups. Streaming mode doesn't update the
+	// retention manifest (see TrimBefore, TrimByCount) - those currently
+	// only track groups written through the in-memory path.
+	Streaming bool
+
+	// ScratchDir is where Streaming mode writes its per-group output
+	// files before moving them into outputDir. Defaults to outputDir
+	// itself, in which case no move is needed.
+	ScratchDir string
+
+	// MaxOpenFiles bounds how many group output files Streaming mode
+	// keeps open at once, closing the least recently used one (and
+	// reopening it in append mode later, if more rows for that group
+	// arrive) once the limit is reached. Defaults to defaultMaxOpenFiles.
+	MaxOpenFiles int
+
+	// Checksum, when true, writes a parallel .sum sidecar file alongside
+	// every output CSV recording a CRC32C digest every ChecksumInterval
+	// bytes, so Verify can later detect bit-rot without re-running the
+	// full sort. Only supported when Streaming is false; SortCSVWithOptions
+	// returns an error if both are set.
+	Checksum bool
+
+	// ChecksumInterval is how many bytes of an output CSV a single
+	// checksum sidecar entry covers. Zero or negative selects
+	// defaultChecksumInterval (64 KiB).
+	ChecksumInterval int
+
+	// Exporter, if non-nil, receives every row alongside the group's CSV
+	// output (see Exporter's doc comment). Only supported when Streaming
+	// is false; SortCSVWithOptions returns an error if both are set,
+	// since Streaming rows never pass through writeGroups.
+	Exporter Exporter
+
+	// Encoding names the input file's text encoding, decoded to UTF-8
+	// before it reaches encoding/csv - see textenc.Decode for the
+	// supported names. Empty selects plain UTF-8 (a BOM, if present, is
+	// still stripped), matching SortCSV's behavior before Encoding
+	// existed; textenc.Auto sniffs a byte-order mark instead.
+	Encoding string
+}
+
+// sortCSVStreaming is SortOptions.Streaming's entry point: it mirrors
+// sortCSVInMemory's CSV parsing and grouping (row by row, without
+// ChunkSize batches), but appends each row
+// directly to its group's scratch file instead of buffering it in a
+// map, via an LRU of bounded open file descriptors.
+func sortCSVStreaming(inputPath, outputDir string, skipErrors bool, errorLog *os.File, opts SortOptions) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	scratchDir := opts.ScratchDir
+	if scratchDir == "" {
+		scratchDir = outputDir
+	}
+	if err := os.MkdirAll(scratchDir, 0755); err != nil {
+		return fmt.Errorf("error creating scratch directory: %v", err)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+	content, err = textenc.Decode(content, opts.Encoding)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("error reading header: %v", err)
+	}
+
+	colMap := make(map[string]int)
+	for i, col := range header {
+		colMap[col] = i
+	}
+
+	required := []string{"Time", "DesignName", "LastAmp"}
+	for _, col := range required {
+		if _, ok := colMap[col]; !ok {
+			return fmt.Errorf("missing required column: %s", col)
+		}
+	}
+
+	timeIdx := colMap["Time"]
+	designIdx := colMap["DesignName"]
+	ampIdx := colMap["LastAmp"]
+
+	group := newGroupFileLRU(scratchDir, header, opts.MaxOpenFiles)
+	defer group.closeAll()
+
+	rowCount := 0
+	skippedRows := 0
+	baseName := filepath.Base(inputPath)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if skipErrors {
+				if errorLog != nil {
+					fmt.Fprintf(errorLog, "File: %s, Row %d: error reading row: %v\n", baseName, rowCount+2, err)
+				}
+				skippedRows++
+				continue
+			}
+			return fmt.Errorf("error reading row %d: %v", rowCount+2, err)
+		}
+		rowCount++
+
+		date, err := parseDate(record[timeIdx])
+		if err != nil {
+			if skipErrors {
+				if errorLog != nil {
+					fmt.Fprintf(errorLog, "File: %s, Row %d: error parsing date from '%s': %v\n", baseName, rowCount+1, record[timeIdx], err)
+				}
+				skippedRows++
+				continue
+			}
+			return fmt.Errorf("error parsing date from '%s': %v", record[timeIdx], err)
+		}
+
+		key := GroupKey{
+			Date:       date,
+			DesignName: normalizeDesign(record[designIdx]),
+			Amp:        normalizeAmp(record[ampIdx]),
+		}
+
+		if err := group.writeRow(key, record); err != nil {
+			return fmt.Errorf("error writing row to group %v: %v", key, err)
+		}
+	}
+
+	if skipErrors && skippedRows > 0 && errorLog != nil {
+		fmt.Fprintf(errorLog, "File: %s - Total skipped rows during sorting: %d\n", baseName, skippedRows)
+	}
+
+	if err := group.closeAll(); err != nil {
+		return err
+	}
+
+	if scratchDir != outputDir {
+		if err := group.moveAll(outputDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// groupOutput tracks the state streaming mode needs per group: its final
+// destination path, whether the header has been written yet (this
+// persists across the file being closed and reopened by the LRU), and
+// the currently-open writer, if any.
+type groupOutput struct {
+	path          string
+	headerWritten bool
+	file          *os.File
+	writer        *csv.Writer
+	elem          *list.Element // position in the LRU, nil when closed
+}
+
+// groupFileLRU bounds how many group output files are open at once,
+// evicting (closing) the least recently used when a new group needs to
+// be opened and the limit has been reached. Evicted groups are reopened
+// in append mode the next time a row for them arrives.
+type groupFileLRU struct {
+	dir    string
+	header []string
+	max    int
+	groups map[GroupKey]*groupOutput
+	lru    *list.List // front = most recently used
+}
+
+func newGroupFileLRU(dir string, header []string, maxOpenFiles int) *groupFileLRU {
+	if maxOpenFiles <= 0 {
+		maxOpenFiles = defaultMaxOpenFiles
+	}
+	return &groupFileLRU{
+		dir:    dir,
+		header: header,
+		max:    maxOpenFiles,
+		groups: make(map[GroupKey]*groupOutput),
+		lru:    list.New(),
+	}
+}
+
+// writeRow appends row to key's group file, opening (or reopening) it if
+// necessary and evicting the least recently used open group first if the
+// LRU is at capacity.
+func (g *groupFileLRU) writeRow(key GroupKey, row []string) error {
+	out, ok := g.groups[key]
+	if !ok {
+		filename := sanitizeFilename(generateFilename(key.Date, key.DesignName, key.Amp))
+		out = &groupOutput{path: filepath.Join(g.dir, filename)}
+		g.groups[key] = out
+	}
+
+	if out.file == nil {
+		if err := g.open(key, out); err != nil {
+			return err
+		}
+	} else {
+		g.lru.MoveToFront(out.elem)
+	}
+
+	return out.writer.Write(row)
+}
+
+// open opens (or creates) out's file, evicting the least recently used
+// open group first if the LRU is already at capacity.
+func (g *groupFileLRU) open(key GroupKey, out *groupOutput) error {
+	for g.lru.Len() >= g.max {
+		if err := g.evictOldest(); err != nil {
+			return err
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	f, err := os.OpenFile(out.path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening group file %s: %v", out.path, err)
+	}
+
+	out.file = f
+	out.writer = csv.NewWriter(f)
+	out.elem = g.lru.PushFront(key)
+
+	if !out.headerWritten {
+		if err := out.writer.Write(g.header); err != nil {
+			return fmt.Errorf("error writing header to %s: %v", out.path, err)
+		}
+		out.headerWritten = true
+	}
+
+	return nil
+}
+
+// evictOldest flushes and closes the least recently used open group,
+// leaving it eligible to be reopened (in append mode) on its next row.
+func (g *groupFileLRU) evictOldest() error {
+	oldest := g.lru.Back()
+	if oldest == nil {
+		return nil
+	}
+	key := oldest.Value.(GroupKey)
+	out := g.groups[key]
+
+	if err := closeGroupFile(out); err != nil {
+		return err
+	}
+	g.lru.Remove(oldest)
+	out.elem = nil
+	return nil
+}
+
+// closeGroupFile flushes out's csv.Writer and closes its file, leaving
+// out open for reopening later.
+func closeGroupFile(out *groupOutput) error {
+	if out.file == nil {
+		return nil
+	}
+	out.writer.Flush()
+	flushErr := out.writer.Error()
+	closeErr := out.file.Close()
+	out.file = nil
+	out.writer = nil
+	if flushErr != nil {
+		return fmt.Errorf("error flushing %s: %v", out.path, flushErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("error closing %s: %v", out.path, closeErr)
+	}
+	return nil
+}
+
+// closeAll flushes and closes every currently open group file. Safe to
+// call more than once.
+func (g *groupFileLRU) closeAll() error {
+	for _, out := range g.groups {
+		if err := closeGroupFile(out); err != nil {
+			return err
+		}
+	}
+	g.lru.Init()
+	return nil
+}
+
+// moveAll moves every group's finished scratch file into outputDir,
+// falling back to copy-then-remove when os.Rename fails (e.g. scratchDir
+// and outputDir are on different filesystems).
+func (g *groupFileLRU) moveAll(outputDir string) error {
+	for _, out := range g.groups {
+		dest := filepath.Join(outputDir, filepath.Base(out.path))
+		if out.path == dest {
+			continue
+		}
+		if err := os.Rename(out.path, dest); err != nil {
+			if err := copyAndRemove(out.path, dest); err != nil {
+				return fmt.Errorf("error moving %s to %s: %v", out.path, dest, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyAndRemove copies src to dest and removes src, used as a fallback
+// when os.Rename can't do an atomic move across filesystems.
+func copyAndRemove(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := in.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,86 @@
+package sorter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSortCSVWithOptionsStreaming verifies streaming mode produces the
+// same grouped output as the in-memory path for a small file.
+func TestSortCSVWithOptionsStreaming(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+2025/Oct/01 09:22:49.600,101,201,5.1,2,Design1,0.97,4
+2025/Oct/01 09:22:49.700,102,202,5.2,2,Design2,2.10,4
+2025/Oct/02 10:00:00.000,103,203,5.4,3,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := SortCSVWithOptions(testCSV, outputDir, false, nil, SortOptions{Streaming: true, MaxOpenFiles: 1})
+	if err != nil {
+		t.Fatalf("SortCSVWithOptions (streaming) failed: %v", err)
+	}
+
+	expectedFiles := []string{
+		"2025-10-01designDesign1amp097.csv",
+		"2025-10-01designDesign2amp210.csv",
+		"2025-10-02designDesign1amp097.csv",
+	}
+	for _, filename := range expectedFiles {
+		path := filepath.Join(outputDir, filename)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			t.Errorf("Expected file %s does not exist", filename)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "2025-10-01designDesign1amp097.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 { // header + 2 rows
+		t.Errorf("Expected 3 lines (header + 2 data), got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Time,") {
+		t.Errorf("Expected header to start with 'Time,', got: %s", lines[0])
+	}
+}
+
+// TestSortCSVWithOptionsStreamingScratchDir verifies streaming mode
+// moves finished group files from a scratch directory into outputDir.
+func TestSortCSVWithOptionsStreamingScratchDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	scratchDir := filepath.Join(tmpDir, "scratch")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,DesignName,LastAmp,TargPassCount
+2025/Oct/01 09:30:02.800,100,200,5.0,2,Design1,0.97,4
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SortOptions{Streaming: true, ScratchDir: scratchDir}
+	if err := SortCSVWithOptions(testCSV, outputDir, false, nil, opts); err != nil {
+		t.Fatalf("SortCSVWithOptions (streaming, scratch dir) failed: %v", err)
+	}
+
+	expectedFile := filepath.Join(outputDir, "2025-10-01designDesign1amp097.csv")
+	if _, err := os.Stat(expectedFile); os.IsNotExist(err) {
+		t.Error("Expected output file does not exist in outputDir")
+	}
+
+	leftoverInScratch, _ := filepath.Glob(filepath.Join(scratchDir, "*.csv"))
+	if len(leftoverInScratch) != 0 {
+		t.Errorf("Expected scratch dir to be empty after move, found: %v", leftoverInScratch)
+	}
+}
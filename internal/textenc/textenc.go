@@ -0,0 +1,113 @@
+// Package textenc decodes non-UTF-8 CSV exports (cp1252 from older
+// Windows tooling, UTF-16 from Excel's "Unicode Text" export) to UTF-8
+// before they reach encoding/csv.
+//
+// It does not vendor golang.org/x/text: this module has no go.mod and
+// no way to fetch external dependencies in this environment, so
+// multi-byte CJK encodings (Shift-JIS, GBK) aren't supported here -
+// Decode returns a descriptive error for them rather than silently
+// mangling the input. cp1252 and UTF-16 cover the field exports we've
+// actually seen; add a table-driven decoder here if a Shift-JIS/GBK
+// source shows up.
+package textenc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Auto is the -encoding flag value that selects BOM-based detection.
+const Auto = "auto"
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+var utf16LEBOM = []byte{0xFF, 0xFE}
+var utf16BEBOM = []byte{0xFE, 0xFF}
+
+// Decode converts content from the named encoding to UTF-8. An empty
+// name is a synonym for "utf8". Auto sniffs a leading byte-order mark,
+// falling back to UTF-8 (with its own BOM, if any, stripped) when none
+// is found - the behavior every caller had before -encoding existed.
+func Decode(content []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "utf8", "utf-8":
+		return stripUTF8BOM(content), nil
+	case Auto:
+		switch {
+		case hasPrefix(content, utf8BOM):
+			return stripUTF8BOM(content), nil
+		case hasPrefix(content, utf16LEBOM):
+			return decodeUTF16(content[len(utf16LEBOM):], binary.LittleEndian)
+		case hasPrefix(content, utf16BEBOM):
+			return decodeUTF16(content[len(utf16BEBOM):], binary.BigEndian)
+		default:
+			return content, nil
+		}
+	case "cp1252", "windows-1252":
+		return decodeCP1252(content), nil
+	case "utf16le":
+		return decodeUTF16(stripBOM(content, utf16LEBOM), binary.LittleEndian)
+	case "utf16be":
+		return decodeUTF16(stripBOM(content, utf16BEBOM), binary.BigEndian)
+	case "shift-jis", "sjis", "gbk", "gb18030":
+		return nil, fmt.Errorf("textenc: encoding %q requires golang.org/x/text, which this build doesn't vendor", encoding)
+	default:
+		return nil, fmt.Errorf("textenc: unknown encoding %q", encoding)
+	}
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
+
+func stripUTF8BOM(content []byte) []byte {
+	return []byte(stripBOM(content, utf8BOM))
+}
+
+func stripBOM(content, bom []byte) []byte {
+	if hasPrefix(content, bom) {
+		return content[len(bom):]
+	}
+	return content
+}
+
+// cp1252HighRange holds cp1252's mapping to Unicode for byte values
+// 0x80-0x9F, the only range where it differs from ISO-8859-1 (every
+// other byte value maps to the identical code point).
+var cp1252HighRange = [32]rune{
+	0x20AC, 0xFFFD, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0xFFFD, 0x017D, 0xFFFD,
+	0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0xFFFD, 0x017E, 0x0178,
+}
+
+func decodeCP1252(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	var buf [utf8.UTFMax]byte
+	for _, b := range content {
+		var r rune
+		if b >= 0x80 && b <= 0x9F {
+			r = cp1252HighRange[b-0x80]
+		} else {
+			r = rune(b)
+		}
+		n := utf8.EncodeRune(buf[:], r)
+		out = append(out, buf[:n]...)
+	}
+	return out
+}
+
+// decodeUTF16 decodes content as a stream of 16-bit code units in the
+// given byte order into UTF-8, handling surrogate pairs.
+func decodeUTF16(content []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(content)%2 != 0 {
+		return nil, fmt.Errorf("textenc: odd-length UTF-16 input (%d bytes)", len(content))
+	}
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[i*2 : i*2+2])
+	}
+	runes := utf16.Decode(units)
+	return []byte(string(runes)), nil
+}
@@ -0,0 +1,66 @@
+package textenc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeUTF8Default(t *testing.T) {
+	in := append(append([]byte{}, utf8BOM...), []byte("Name,Value\n")...)
+	got, err := Decode(in, "")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if string(got) != "Name,Value\n" {
+		t.Errorf("got %q, want BOM stripped", got)
+	}
+}
+
+func TestDecodeCP1252(t *testing.T) {
+	// 0x91/0x92 are cp1252's curly single quotes; 0xE9 is e-acute, shared
+	// with ISO-8859-1.
+	in := []byte{0x91, 'h', 'i', 0x92, ',', 0xE9}
+	got, err := Decode(in, "cp1252")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	want := "‘hi’,é"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDecodeUTF16LEWithBOM(t *testing.T) {
+	var in []byte
+	in = append(in, utf16LEBOM...)
+	for _, r := range "Name,Value" {
+		in = append(in, byte(r), 0)
+	}
+	got, err := Decode(in, Auto)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if string(got) != "Name,Value" {
+		t.Errorf("got %q, want %q", got, "Name,Value")
+	}
+}
+
+func TestDecodeUnsupportedEncoding(t *testing.T) {
+	if _, err := Decode([]byte("x"), "shift-jis"); err == nil {
+		t.Fatal("expected an error for shift-jis, got nil")
+	}
+	if _, err := Decode([]byte("x"), "bogus"); err == nil {
+		t.Fatal("expected an error for an unknown encoding name, got nil")
+	}
+}
+
+func TestDecodeAutoNoBOMFallsThroughToUTF8(t *testing.T) {
+	in := []byte("Name,Value\n")
+	got, err := Decode(in, Auto)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !bytes.Equal(got, in) {
+		t.Errorf("got %q, want input unchanged", got)
+	}
+}
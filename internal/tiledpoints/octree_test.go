@@ -0,0 +1,89 @@
+package tiledpoints
+
+import "testing"
+
+// TestBuildOctreeSplitsOverLimit verifies a point set larger than
+// maxPointsPerNode is split into children whose leaves never exceed it,
+// and that every original point still appears exactly once across all
+// leaves.
+func TestBuildOctreeSplitsOverLimit(t *testing.T) {
+	var points []Point
+	for i := 0; i < 100; i++ {
+		x := float64(i % 10)
+		y := float64((i / 10) % 10)
+		points = append(points, Point{X: x, Y: y, Z: 0, Index: i})
+	}
+
+	root := BuildOctree(points, 10)
+	leaves := Leaves(root, nil)
+
+	if len(leaves) <= 1 {
+		t.Fatalf("expected more than one leaf for 100 points over a limit of 10, got %d", len(leaves))
+	}
+
+	seen := make(map[int]bool)
+	for _, leaf := range leaves {
+		if len(leaf.Points) > 10 {
+			t.Errorf("leaf %+v has %d points, want <= 10", leaf.Key, len(leaf.Points))
+		}
+		for _, p := range leaf.Points {
+			if seen[p.Index] {
+				t.Errorf("point index %d appears in more than one leaf", p.Index)
+			}
+			seen[p.Index] = true
+		}
+	}
+	if len(seen) != len(points) {
+		t.Errorf("got %d distinct points across leaves, want %d", len(seen), len(points))
+	}
+}
+
+// TestBuildOctreeUnderLimitStaysRoot verifies a small point set isn't
+// split at all: the root is returned as a single leaf.
+func TestBuildOctreeUnderLimitStaysRoot(t *testing.T) {
+	points := []Point{{X: 0, Y: 0, Z: 0, Index: 0}, {X: 1, Y: 1, Z: 1, Index: 1}}
+
+	root := BuildOctree(points, 100)
+	leaves := Leaves(root, nil)
+
+	if len(leaves) != 1 {
+		t.Fatalf("expected 1 leaf, got %d", len(leaves))
+	}
+	if len(leaves[0].Points) != 2 {
+		t.Errorf("expected the root leaf to hold both points, got %d", len(leaves[0].Points))
+	}
+}
+
+// TestBuildOctreeCoincidentPointsDontRecurseForever verifies a node
+// whose points can't be separated by further subdivision (all identical
+// coordinates) is kept as one oversized leaf instead of hitting maxDepth
+// through endless fruitless splitting.
+func TestBuildOctreeCoincidentPointsDontRecurseForever(t *testing.T) {
+	var points []Point
+	for i := 0; i < 50; i++ {
+		points = append(points, Point{X: 1, Y: 1, Z: 1, Index: i})
+	}
+
+	root := BuildOctree(points, 10)
+	leaves := Leaves(root, nil)
+
+	if len(leaves) != 1 {
+		t.Fatalf("expected coincident points to stay in 1 leaf, got %d", len(leaves))
+	}
+	if len(leaves[0].Points) != 50 {
+		t.Errorf("expected the leaf to hold all 50 points, got %d", len(leaves[0].Points))
+	}
+}
+
+// TestBuildOctreeDefaultMaxPointsPerNode verifies maxPointsPerNode <= 0
+// selects DefaultMaxPointsPerNode rather than splitting every node.
+func TestBuildOctreeDefaultMaxPointsPerNode(t *testing.T) {
+	points := []Point{{X: 0, Y: 0, Z: 0, Index: 0}, {X: 1, Y: 1, Z: 1, Index: 1}}
+
+	root := BuildOctree(points, 0)
+	leaves := Leaves(root, nil)
+
+	if len(leaves) != 1 {
+		t.Errorf("expected a small point set to stay in 1 leaf under the default limit, got %d", len(leaves))
+	}
+}
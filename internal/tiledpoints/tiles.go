@@ -0,0 +1,99 @@
+package tiledpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// ToPoints converts LAS points into the minimal Point slice BuildOctree
+// partitions on, preserving each point's original slice index so
+// WriteTiles can recover the full las.Point for every leaf afterwards.
+func ToPoints(points []las.Point) []Point {
+	out := make([]Point, len(points))
+	for i, p := range points {
+		out[i] = Point{X: p.X, Y: p.Y, Z: p.Z, Index: i}
+	}
+	return out
+}
+
+// Hierarchy is the JSON manifest WriteTiles writes alongside the
+// per-node LAS files: for each leaf node, which file holds its points,
+// how many points it has, and its spatial bounds - enough for a viewer
+// to decide which files are relevant to the region and zoom level it
+// needs before reading any of them.
+type Hierarchy struct {
+	Bounds Bounds          `json:"bounds"`
+	Nodes  []HierarchyNode `json:"nodes"`
+}
+
+// HierarchyNode describes one leaf node written by WriteTiles.
+type HierarchyNode struct {
+	Key        string `json:"key"`
+	File       string `json:"file"`
+	PointCount int    `json:"pointCount"`
+	Bounds     Bounds `json:"bounds"`
+}
+
+// fileName returns the LAS file name a leaf's points are written to,
+// named after its octree key so file names sort and grep predictably.
+func fileName(k Key) string {
+	return fmt.Sprintf("%d-%d-%d-%d.las", k.Level, k.X, k.Y, k.Z)
+}
+
+// WriteTiles writes one plain LAS 1.2 point-format-3 file per leaf of
+// root into dir, plus a hierarchy.json manifest (see Hierarchy),
+// performing all file I/O through fsys. source must be the same
+// []las.Point slice that was converted with ToPoints to build root:
+// WriteTiles uses each leaf Point's Index to recover its full las.Point
+// (color, classification, GPS time, ...) rather than just the X/Y/Z the
+// octree partitioned on. A leaf with no points (only possible for an
+// empty root covering zero input points) is skipped rather than handed
+// to las.Writer, which errors on an empty point set.
+func WriteTiles(fsys fsutil.Filesystem, dir string, root *Node, source []las.Point) (Hierarchy, error) {
+	if err := fsys.MkdirAll(dir, 0755); err != nil {
+		return Hierarchy{}, fmt.Errorf("error creating tile directory: %v", err)
+	}
+
+	hierarchy := Hierarchy{Bounds: root.Bounds}
+
+	for _, leaf := range Leaves(root, nil) {
+		if len(leaf.Points) == 0 {
+			continue
+		}
+
+		name := fileName(leaf.Key)
+		writer := las.NewWriterFS(fsys)
+		for _, pt := range leaf.Points {
+			writer.AddPoint(source[pt.Index])
+		}
+		if err := writer.Write(filepath.Join(dir, name)); err != nil {
+			return Hierarchy{}, fmt.Errorf("error writing tile %s: %v", name, err)
+		}
+
+		hierarchy.Nodes = append(hierarchy.Nodes, HierarchyNode{
+			Key:        fmt.Sprintf("%d-%d-%d-%d", leaf.Key.Level, leaf.Key.X, leaf.Key.Y, leaf.Key.Z),
+			File:       name,
+			PointCount: len(leaf.Points),
+			Bounds:     leaf.Bounds,
+		})
+	}
+
+	data, err := json.MarshalIndent(hierarchy, "", "  ")
+	if err != nil {
+		return Hierarchy{}, fmt.Errorf("error encoding hierarchy manifest: %v", err)
+	}
+	w, err := fsys.Create(filepath.Join(dir, "hierarchy.json"))
+	if err != nil {
+		return Hierarchy{}, fmt.Errorf("error creating hierarchy manifest: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+	if _, err := w.Write(data); err != nil {
+		return Hierarchy{}, fmt.Errorf("error writing hierarchy manifest: %v", err)
+	}
+
+	return hierarchy, nil
+}
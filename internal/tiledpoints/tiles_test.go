@@ -0,0 +1,67 @@
+package tiledpoints
+
+import (
+	"encoding/json"
+	"testing"
+
+	"compactmapper/internal/fsutil"
+	"compactmapper/las"
+)
+
+// TestWriteTilesRoundTrips builds a small octree over enough points to
+// force a split, writes it out, and verifies every leaf's LAS file
+// round-trips the right point count and that hierarchy.json accounts
+// for every point exactly once.
+func TestWriteTilesRoundTrips(t *testing.T) {
+	var source []las.Point
+	for i := 0; i < 40; i++ {
+		source = append(source, las.Point{
+			X: float64(i % 10), Y: float64((i / 10) % 10), Z: 0,
+			R: uint16(i), Classification: 1,
+		})
+	}
+
+	root := BuildOctree(ToPoints(source), 10)
+
+	fsys := fsutil.NewMemFilesystem()
+	hierarchy, err := WriteTiles(fsys, "tiles", root, source)
+	if err != nil {
+		t.Fatalf("WriteTiles failed: %v", err)
+	}
+
+	if len(hierarchy.Nodes) <= 1 {
+		t.Fatalf("expected more than one tile for 40 points over a limit of 10, got %d", len(hierarchy.Nodes))
+	}
+
+	totalPoints := 0
+	for _, node := range hierarchy.Nodes {
+		reader, err := las.NewReaderFS(fsys, "tiles/"+node.File)
+		if err != nil {
+			t.Fatalf("NewReaderFS(%s) failed: %v", node.File, err)
+		}
+		points, err := reader.ReadPoints()
+		if err != nil {
+			t.Fatalf("ReadPoints(%s) failed: %v", node.File, err)
+		}
+		if len(points) != node.PointCount {
+			t.Errorf("tile %s: hierarchy says %d points, file has %d", node.File, node.PointCount, len(points))
+		}
+		totalPoints += len(points)
+	}
+	if totalPoints != len(source) {
+		t.Errorf("tiles hold %d points total, want %d", totalPoints, len(source))
+	}
+
+	manifestRaw, err := fsys.Open("tiles/hierarchy.json")
+	if err != nil {
+		t.Fatalf("hierarchy.json was not written: %v", err)
+	}
+	defer manifestRaw.Close()
+	var decoded Hierarchy
+	if err := json.NewDecoder(manifestRaw).Decode(&decoded); err != nil {
+		t.Fatalf("hierarchy.json isn't valid JSON: %v", err)
+	}
+	if len(decoded.Nodes) != len(hierarchy.Nodes) {
+		t.Errorf("decoded hierarchy has %d nodes, want %d", len(decoded.Nodes), len(hierarchy.Nodes))
+	}
+}
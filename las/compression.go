@@ -0,0 +1,23 @@
+package las
+
+// Compression selects whether Write emits a plain LAS file or delegates
+// to the LAZ pipeline. The zero value, CompressionNone, is the
+// long-standing default.
+type Compression int
+
+const (
+	// CompressionNone writes an uncompressed LAS file (the default).
+	CompressionNone Compression = iota
+	// CompressionLAZ writes a LASzip-compressed file via WriteLAZ - see
+	// its doc comment for how compression is actually performed.
+	CompressionLAZ
+)
+
+// SetCompression selects whether subsequent Write calls produce a plain
+// LAS file or delegate to WriteLAZ. Callers that always want LAZ output
+// can call WriteLAZ directly instead; SetCompression exists so code that
+// only has a Write(filename) call site (e.g. a generic "save" button)
+// can still honor a user's compression choice.
+func (w *Writer) SetCompression(c Compression) {
+	w.compression = c
+}
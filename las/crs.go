@@ -0,0 +1,128 @@
+package las
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// vlrUserIDGeoTIFF is the LAS spec's reserved user ID for GeoTIFF-derived
+// VLRs (GeoKeyDirectoryTag and the OGC WKT records below).
+const vlrUserIDGeoTIFF = "LASF_Projection"
+
+const (
+	recordIDGeoKeyDirectory = 34735
+	recordIDOGCWKT          = 2112
+)
+
+// CRS tags an LAS file with a coordinate reference system: a
+// GeoKeyDirectoryTag VLR naming the EPSG code, an OGC WKT VLR carrying
+// the full definition, and a sidecar .prj file for tools (older GIS
+// clients, some viewers) that read WKT from a sidecar instead of VLRs.
+//
+// WKT is optional - if empty, SetCRS looks it up in the small bundled
+// EPSGWKT table by EPSG code. A CRS with EPSG == 0 is the zero value and
+// means "no CRS metadata", matching LASFormat's isZero convention.
+type CRS struct {
+	EPSG int
+	WKT  string
+}
+
+func (c CRS) isZero() bool { return c == CRS{} }
+
+// wkt resolves the WKT text to embed: the explicit WKT field if set,
+// otherwise a lookup in the bundled EPSG table, otherwise empty (in
+// which case only the GeoKeyDirectoryTag VLR is written).
+func (c CRS) wkt() string {
+	if c.WKT != "" {
+		return c.WKT
+	}
+	if wkt, ok := EPSGWKT(c.EPSG); ok {
+		return wkt
+	}
+	return ""
+}
+
+// SetCRS tags subsequent Write/WriteLAZ calls with crs: a
+// GeoKeyDirectoryTag VLR (and an OGC WKT VLR, when WKT text is available)
+// are written between the header and the point data, and a sidecar .prj
+// file is written alongside the LAS file.
+func (w *Writer) SetCRS(crs CRS) {
+	w.crs = crs
+}
+
+// vlrRecord is one Variable Length Record: a 54-byte header followed by
+// its data.
+type vlrRecord struct {
+	userID      string
+	recordID    uint16
+	data        []byte
+	description string
+}
+
+func (v vlrRecord) bytes() []byte {
+	out := make([]byte, 54+len(v.data))
+	// out[0:2] reserved, left zero
+	copy(out[2:18], v.userID)
+	binary.LittleEndian.PutUint16(out[18:20], v.recordID)
+	binary.LittleEndian.PutUint16(out[20:22], uint16(len(v.data)))
+	copy(out[22:54], v.description)
+	copy(out[54:], v.data)
+	return out
+}
+
+// crsVLRs builds the VLR records tagging crs, in the order they're
+// written to the file. Returns nil if crs is the zero value.
+func crsVLRs(crs CRS) []vlrRecord {
+	if crs.isZero() {
+		return nil
+	}
+
+	records := []vlrRecord{
+		{
+			userID:      vlrUserIDGeoTIFF,
+			recordID:    recordIDGeoKeyDirectory,
+			description: "GeoKeyDirectoryTag (EPSG " + strconv.Itoa(crs.EPSG) + ")",
+			data:        geoKeyDirectory(crs.EPSG),
+		},
+	}
+
+	if wkt := crs.wkt(); wkt != "" {
+		records = append(records, vlrRecord{
+			userID:      vlrUserIDGeoTIFF,
+			recordID:    recordIDOGCWKT,
+			description: "OGC Coordinate System WKT",
+			data:        []byte(wkt),
+		})
+	}
+
+	return records
+}
+
+// geoKeyDirectory builds the minimal GeoKeyDirectoryTag payload claiming
+// a projected coordinate system identified by epsg: a directory header
+// (version 1.1.0) followed by two entries, GTModelTypeGeoKey set to
+// projected and ProjectedCSTypeGeoKey set to epsg. This
+// doesn't attempt to describe a full geographic/projected/vertical key
+// set - just enough for GIS tools to resolve the EPSG code.
+func geoKeyDirectory(epsg int) []byte {
+	const (
+		gtModelTypeGeoKey     = 1024
+		gtModelTypeProjected  = 1
+		projectedCSTypeGeoKey = 3072
+	)
+
+	keys := []uint16{
+		// KeyDirectoryVersion, KeyRevision, MinorRevision, NumberOfKeys
+		1, 1, 0, 2,
+		// GTModelTypeGeoKey = Projected
+		gtModelTypeGeoKey, 0, 1, gtModelTypeProjected,
+		// ProjectedCSTypeGeoKey = epsg
+		projectedCSTypeGeoKey, 0, 1, uint16(epsg),
+	}
+
+	data := make([]byte, len(keys)*2)
+	for i, k := range keys {
+		binary.LittleEndian.PutUint16(data[i*2:i*2+2], k)
+	}
+	return data
+}
@@ -0,0 +1,104 @@
+package las
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWriteWithCRSEmitsVLRsAndSidecar verifies SetCRS shifts the point
+// data offset past a GeoKeyDirectoryTag + OGC WKT VLR pair and writes a
+// matching .prj sidecar.
+func TestWriteWithCRSEmitsVLRsAndSidecar(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "test.las")
+
+	writer := NewWriter()
+	writer.SetCRS(CRS{EPSG: 32610})
+	writer.AddPoint(Point{X: 1, Y: 2, Z: 3})
+
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	header := reader.GetHeader()
+	if header.OffsetToPointData <= 227 {
+		t.Errorf("OffsetToPointData = %d, want > 227 (VLRs should push point data back)", header.OffsetToPointData)
+	}
+	if header.NumberOfVLRs != 2 {
+		t.Errorf("NumberOfVLRs = %d, want 2 (GeoKeyDirectoryTag + OGC WKT)", header.NumberOfVLRs)
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != 1 {
+		t.Fatalf("got %d points, want 1", len(points))
+	}
+
+	prjData, err := os.ReadFile(filepath.Join(tmpDir, "test.prj"))
+	if err != nil {
+		t.Fatalf("expected a .prj sidecar: %v", err)
+	}
+	if !strings.Contains(string(prjData), "UTM zone 10N") {
+		t.Errorf(".prj content = %q, want it to mention UTM zone 10N", prjData)
+	}
+}
+
+// TestWriteWithoutCRSOmitsVLRs verifies the zero-value CRS (the default)
+// writes exactly the legacy no-VLR layout, so existing callers that
+// never touch SetCRS see no behavior change.
+func TestWriteWithoutCRSOmitsVLRs(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "test.las")
+
+	writer := NewWriter()
+	writer.AddPoint(Point{X: 1, Y: 2, Z: 3})
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	if header := reader.GetHeader(); header.OffsetToPointData != 227 {
+		t.Errorf("OffsetToPointData = %d, want 227 (no VLRs)", header.OffsetToPointData)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test.prj")); !os.IsNotExist(err) {
+		t.Errorf("expected no .prj sidecar without a CRS, stat err = %v", err)
+	}
+}
+
+// TestWriteWithCRSUnknownEPSGFallsBackToBareLabel verifies an EPSG code
+// outside the bundled table still gets a .prj (with a bare "EPSG:code"
+// fallback) and a GeoKeyDirectoryTag VLR, just no WKT VLR.
+func TestWriteWithCRSUnknownEPSGFallsBackToBareLabel(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "test.las")
+
+	writer := NewWriter()
+	writer.SetCRS(CRS{EPSG: 99999})
+	writer.AddPoint(Point{X: 1, Y: 2, Z: 3})
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	prjData, err := os.ReadFile(filepath.Join(tmpDir, "test.prj"))
+	if err != nil {
+		t.Fatalf("expected a .prj sidecar: %v", err)
+	}
+	if string(prjData) != "EPSG:99999" {
+		t.Errorf(".prj content = %q, want %q", prjData, "EPSG:99999")
+	}
+}
@@ -0,0 +1,63 @@
+package las
+
+import "strconv"
+
+// epsgWKT bundles WKT definitions for a handful of coordinate systems
+// commonly seen on CAT roller jobsites: geographic WGS84, Web Mercator,
+// and the WGS84/NAD83 UTM zones covering North America. This is not a
+// full EPSG database - there's no vendored CRS library in this
+// dependency-free repo to draw one from - so for any EPSG code not
+// listed here (and no explicit CRS.WKT) SetCRS writes only the
+// GeoKeyDirectoryTag VLR, with no WKT VLR, and a .prj holding a bare
+// "EPSG:<code>" line.
+var epsgWKT = map[int]string{
+	4326: `GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433],AUTHORITY["EPSG","4326"]]`,
+	3857: `PROJCS["WGS 84 / Pseudo-Mercator",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]],PROJECTION["Mercator_1SP"],UNIT["metre",1],AUTHORITY["EPSG","3857"]]`,
+	32610: `PROJCS["WGS 84 / UTM zone 10N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","32610"]]`,
+	32611: `PROJCS["WGS 84 / UTM zone 11N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","32611"]]`,
+	32612: `PROJCS["WGS 84 / UTM zone 12N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","32612"]]`,
+	32613: `PROJCS["WGS 84 / UTM zone 13N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","32613"]]`,
+	26910: `PROJCS["NAD83 / UTM zone 10N",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","26910"]]`,
+	26911: `PROJCS["NAD83 / UTM zone 11N",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","26911"]]`,
+	26912: `PROJCS["NAD83 / UTM zone 12N",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","26912"]]`,
+	26913: `PROJCS["NAD83 / UTM zone 13N",GEOGCS["NAD83",DATUM["North_American_Datum_1983",SPHEROID["GRS 1980",6378137,298.257222101]]],PROJECTION["Transverse_Mercator"],UNIT["metre",1],AUTHORITY["EPSG","26913"]]`,
+}
+
+// epsgNames labels the bundled codes for GUI autocomplete; order matches
+// CommonEPSGCodes.
+var epsgNames = map[int]string{
+	4326:  "WGS 84 (geographic)",
+	3857:  "WGS 84 / Pseudo-Mercator",
+	32610: "WGS 84 / UTM zone 10N",
+	32611: "WGS 84 / UTM zone 11N",
+	32612: "WGS 84 / UTM zone 12N",
+	32613: "WGS 84 / UTM zone 13N",
+	26910: "NAD83 / UTM zone 10N",
+	26911: "NAD83 / UTM zone 11N",
+	26912: "NAD83 / UTM zone 12N",
+	26913: "NAD83 / UTM zone 13N",
+}
+
+// EPSGWKT looks up the bundled WKT definition for an EPSG code. ok is
+// false for any code outside the small bundled list (see epsgWKT).
+func EPSGWKT(epsg int) (wkt string, ok bool) {
+	wkt, ok = epsgWKT[epsg]
+	return wkt, ok
+}
+
+// CommonEPSGCodes returns the EPSG codes bundled with this package, in a
+// stable order, for UI elements (e.g. the GUI's coordinate system
+// dropdown) that want to offer a short list without shipping a full EPSG
+// database.
+func CommonEPSGCodes() []int {
+	return []int{4326, 3857, 32610, 32611, 32612, 32613, 26910, 26911, 26912, 26913}
+}
+
+// EPSGLabel returns a human-readable name for a bundled EPSG code, or a
+// bare "EPSG:<code>" fallback for codes outside the bundled list.
+func EPSGLabel(epsg int) string {
+	if name, ok := epsgNames[epsg]; ok {
+		return "EPSG:" + strconv.Itoa(epsg) + " - " + name
+	}
+	return "EPSG:" + strconv.Itoa(epsg)
+}
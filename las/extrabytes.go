@@ -0,0 +1,233 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// vlrUserIDLASFSpec is the LAS spec's reserved user ID for its own
+// record types (Extra Bytes among them), distinct from the GeoTIFF
+// user ID crsVLRs tags its records with.
+const vlrUserIDLASFSpec = "LASF_Spec"
+
+const recordIDExtraBytes = 4
+
+// extraBytesDataTypeLong is the Extra Bytes VLR's data_type code for a
+// signed 4-byte integer - what CompactionExtraByteFields encodes every
+// field as, the same scaled-int32 scheme scaledXYZ already uses for
+// coordinates.
+const extraBytesDataTypeLong = 6
+
+// extraBytesOptionsNoDataScaleOffset marks an Extra Bytes field
+// descriptor as carrying no_data, scale, and offset (bits 0, 3, 4) but
+// no min/max - CompactionExtraByteFields doesn't track a running
+// min/max the way Writer does for XYZ, so those bits are left unset.
+const extraBytesOptionsNoDataScaleOffset = 1<<0 | 1<<3 | 1<<4
+
+// ExtraByteField describes one typed per-point attribute carried in a
+// LAS file's Extra Bytes VLR (user ID "LASF_Spec", record ID 4):
+// compaction telemetry that doesn't fit the fixed point formats and
+// would otherwise have to be lossily packed into RGB. Every field is
+// stored per point as a scaled signed 32-bit integer, the same
+// convention scaledXYZ uses for coordinates.
+type ExtraByteField struct {
+	Name   string
+	Scale  float64
+	Offset float64
+	// NoData is the real-world value a point with no recorded sample
+	// for this field reads back as - see ExtraAttributes.
+	NoData float64
+}
+
+// CompactionExtraByteFields is the fixed set, order, and encoding of the
+// compaction attributes Writer.SetExtraBytes(true) attaches to every
+// point: the fields CAT-roller machines report that point formats 2/3/6/7
+// have no room for. PassCount and TotalPasses are whole numbers (Scale
+// 1), the rest carry one decimal place of real precision; LastTemp's
+// NoData sentinel is negative since 0 is itself a plausible temperature.
+var CompactionExtraByteFields = []ExtraByteField{
+	{Name: "LastCMV", Scale: 0.1, Offset: 0, NoData: -1},
+	{Name: "TargCMV", Scale: 0.1, Offset: 0, NoData: -1},
+	{Name: "LastMDP", Scale: 0.1, Offset: 0, NoData: -1},
+	{Name: "LastEVIB1", Scale: 0.1, Offset: 0, NoData: -1},
+	{Name: "LastEVIB2", Scale: 0.1, Offset: 0, NoData: -1},
+	{Name: "PassCount", Scale: 1, Offset: 0, NoData: -1},
+	{Name: "TotalPasses", Scale: 1, Offset: 0, NoData: -1},
+	{Name: "LastTemp", Scale: 0.1, Offset: 0, NoData: -999},
+}
+
+// ExtraAttributes carries the CompactionExtraByteFields values recorded
+// for one point. A field's zero value is a real reading of 0 - missing
+// data is represented by math.NaN(), the same sentinel
+// sorter.parseFloatOrNaN already uses for a blank or "?" CSV cell -
+// which Writer encodes as that field's declared NoData value rather than
+// silently writing 0.
+type ExtraAttributes struct {
+	LastCMV     float64
+	TargCMV     float64
+	LastMDP     float64
+	LastEVIB1   float64
+	LastEVIB2   float64
+	PassCount   float64
+	TotalPasses float64
+	LastTemp    float64
+}
+
+// values returns e's fields in CompactionExtraByteFields order. A nil
+// receiver (AddPoint called with no Extra set) reads as "no data"
+// throughout.
+func (e *ExtraAttributes) values() []float64 {
+	if e == nil {
+		return []float64{
+			math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+			math.NaN(), math.NaN(), math.NaN(), math.NaN(),
+		}
+	}
+	return []float64{
+		e.LastCMV, e.TargCMV, e.LastMDP, e.LastEVIB1,
+		e.LastEVIB2, e.PassCount, e.TotalPasses, e.LastTemp,
+	}
+}
+
+// extraByteFieldDescriptor is one field's already-parsed VLR record, read
+// back from the file's raw Extra Bytes VLR data by parseExtraByteVLRData -
+// this is what lets Reader decode a file's extra bytes using the
+// scale/offset/no_data the file itself declares, rather than assuming
+// CompactionExtraByteFields' own values.
+type extraByteFieldDescriptor struct {
+	Name     string
+	DataType uint8
+	Scale    float64
+	Offset   float64
+	NoData   float64
+}
+
+// extraBytesVLR builds the Extra Bytes VLR record describing
+// CompactionExtraByteFields, written once per file by writeUncompressed
+// when Writer.SetExtraBytes(true).
+func extraBytesVLR() vlrRecord {
+	return vlrRecord{
+		userID:      vlrUserIDLASFSpec,
+		recordID:    recordIDExtraBytes,
+		description: "Extra Bytes (compaction telemetry)",
+		data:        encodeExtraByteVLRData(CompactionExtraByteFields),
+	}
+}
+
+// extraByteFieldStructSize is the size in bytes of one "Extra Bytes
+// Struct" record inside the Extra Bytes VLR's data, per the LAS 1.4
+// spec: reserved(2) + data_type(1) + options(1) + name(32) + unused(4)
+// + no_data(24) + min(24) + max(24) + scale(24) + offset(24) +
+// description(32).
+const extraByteFieldStructSize = 192
+
+// encodeExtraByteVLRData encodes fields as the Extra Bytes VLR's data
+// payload: one extraByteFieldStructSize-byte struct per field, in order.
+func encodeExtraByteVLRData(fields []ExtraByteField) []byte {
+	data := make([]byte, extraByteFieldStructSize*len(fields))
+	for i, f := range fields {
+		rec := data[i*extraByteFieldStructSize : (i+1)*extraByteFieldStructSize]
+		rec[2] = extraBytesDataTypeLong
+		rec[3] = extraBytesOptionsNoDataScaleOffset
+		copy(rec[4:36], f.Name)
+		// no_data[0], scale[0], offset[0]: only the first of each
+		// 3-element array is used, matching data_type 6 (a scalar, not
+		// a 2/3-element type).
+		binary.LittleEndian.PutUint64(rec[40:48], math.Float64bits(f.NoData))
+		binary.LittleEndian.PutUint64(rec[112:120], math.Float64bits(f.Scale))
+		binary.LittleEndian.PutUint64(rec[136:144], math.Float64bits(f.Offset))
+	}
+	return data
+}
+
+// parseExtraByteVLRData decodes an Extra Bytes VLR's data payload back
+// into one descriptor per field, the inverse of encodeExtraByteVLRData.
+func parseExtraByteVLRData(data []byte) ([]extraByteFieldDescriptor, error) {
+	if len(data)%extraByteFieldStructSize != 0 {
+		return nil, fmt.Errorf("malformed Extra Bytes VLR: %d bytes is not a multiple of %d", len(data), extraByteFieldStructSize)
+	}
+	n := len(data) / extraByteFieldStructSize
+	descriptors := make([]extraByteFieldDescriptor, n)
+	for i := 0; i < n; i++ {
+		rec := data[i*extraByteFieldStructSize : (i+1)*extraByteFieldStructSize]
+		name := rec[4:36]
+		if nul := bytes.IndexByte(name, 0); nul >= 0 {
+			name = name[:nul]
+		}
+		descriptors[i] = extraByteFieldDescriptor{
+			Name:     string(name),
+			DataType: rec[2],
+			NoData:   math.Float64frombits(binary.LittleEndian.Uint64(rec[40:48])),
+			Scale:    math.Float64frombits(binary.LittleEndian.Uint64(rec[112:120])),
+			Offset:   math.Float64frombits(binary.LittleEndian.Uint64(rec[136:144])),
+		}
+	}
+	return descriptors, nil
+}
+
+// encodeExtraBytes encodes one point's extra attributes as
+// len(CompactionExtraByteFields)*4 bytes of scaled int32 values, the
+// payload Writer appends after every point record when extraBytes is
+// enabled. A NaN field (math.NaN() - see ExtraAttributes) writes that
+// field's declared NoData value instead of the scaled garbage
+// int32(NaN) would otherwise produce.
+func encodeExtraBytes(e *ExtraAttributes) []byte {
+	values := e.values()
+	out := make([]byte, 4*len(CompactionExtraByteFields))
+	for i, f := range CompactionExtraByteFields {
+		v := values[i]
+		if math.IsNaN(v) {
+			v = f.NoData
+		}
+		scaled := int32(math.Round((v - f.Offset) / f.Scale))
+		binary.LittleEndian.PutUint32(out[i*4:i*4+4], uint32(scaled))
+	}
+	return out
+}
+
+// decodeExtraBytes decodes data (as written by encodeExtraBytes) back
+// into an ExtraAttributes, using descriptors' declared scale/offset/
+// no_data rather than CompactionExtraByteFields' own values - a point
+// read from a file this package didn't write should be decoded using
+// what that file's own VLR says, not this build's compiled-in defaults.
+// A value equal to its field's NoData becomes NaN, the inverse of
+// encodeExtraBytes' NaN -> NoData substitution. Fields in descriptors
+// with no matching name in ExtraAttributes are ignored.
+func decodeExtraBytes(data []byte, descriptors []extraByteFieldDescriptor) *ExtraAttributes {
+	e := &ExtraAttributes{
+		LastCMV: math.NaN(), TargCMV: math.NaN(), LastMDP: math.NaN(),
+		LastEVIB1: math.NaN(), LastEVIB2: math.NaN(), PassCount: math.NaN(),
+		TotalPasses: math.NaN(), LastTemp: math.NaN(),
+	}
+	for i, d := range descriptors {
+		if (i+1)*4 > len(data) {
+			break
+		}
+		scaled := int32(binary.LittleEndian.Uint32(data[i*4 : i*4+4]))
+		v := float64(scaled)*d.Scale + d.Offset
+		if v == d.NoData {
+			v = math.NaN()
+		}
+		switch d.Name {
+		case "LastCMV":
+			e.LastCMV = v
+		case "TargCMV":
+			e.TargCMV = v
+		case "LastMDP":
+			e.LastMDP = v
+		case "LastEVIB1":
+			e.LastEVIB1 = v
+		case "LastEVIB2":
+			e.LastEVIB2 = v
+		case "PassCount":
+			e.PassCount = v
+		case "TotalPasses":
+			e.TotalPasses = v
+		case "LastTemp":
+			e.LastTemp = v
+		}
+	}
+	return e
+}
@@ -0,0 +1,124 @@
+package las
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// TestExtraBytesRoundTrip verifies a file written with SetExtraBytes(true)
+// carries an Extra Bytes VLR (LASF_Spec/4) whose field descriptors Reader
+// parses back, and that each point's compaction attributes - including a
+// "no data" (NaN) field - round-trip through the scaled int32 encoding.
+func TestExtraBytesRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "extra.las")
+
+	writer := NewWriter()
+	if err := writer.SetFormat(Version{1, 4}, 7); err != nil {
+		t.Fatalf("SetFormat failed: %v", err)
+	}
+	writer.SetExtraBytes(true)
+
+	writer.AddPoint(Point{
+		X: 100.5, Y: 200.3, Z: 5.2, R: 65535, GPSTime: 1727776202.8,
+		Extra: &ExtraAttributes{
+			LastCMV: 45.2, TargCMV: 50.0, LastMDP: math.NaN(),
+			LastEVIB1: 12.3, LastEVIB2: math.NaN(),
+			PassCount: 4, TotalPasses: 6, LastTemp: -999,
+		},
+	})
+	// A point with no Extra set at all - every field should read back NaN.
+	writer.AddPoint(Point{X: 101.0, Y: 201.0, Z: 5.3, GPSTime: 1727776203.0})
+
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	header := reader.GetHeader()
+	wantRecordLength := uint16(36 + 4*len(CompactionExtraByteFields))
+	if header.PointRecordLength != wantRecordLength {
+		t.Errorf("PointRecordLength = %d, want %d", header.PointRecordLength, wantRecordLength)
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2", len(points))
+	}
+
+	p0 := points[0]
+	if p0.Extra == nil {
+		t.Fatal("point 0: Extra is nil, want a decoded ExtraAttributes")
+	}
+	checkClose := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 0.05 {
+			t.Errorf("point 0: %s = %v, want %v", name, got, want)
+		}
+	}
+	checkClose("LastCMV", p0.Extra.LastCMV, 45.2)
+	checkClose("TargCMV", p0.Extra.TargCMV, 50.0)
+	checkClose("LastEVIB1", p0.Extra.LastEVIB1, 12.3)
+	checkClose("PassCount", p0.Extra.PassCount, 4)
+	checkClose("TotalPasses", p0.Extra.TotalPasses, 6)
+	if !math.IsNaN(p0.Extra.LastMDP) {
+		t.Errorf("point 0: LastMDP = %v, want NaN (no data)", p0.Extra.LastMDP)
+	}
+	if !math.IsNaN(p0.Extra.LastEVIB2) {
+		t.Errorf("point 0: LastEVIB2 = %v, want NaN (no data)", p0.Extra.LastEVIB2)
+	}
+	// LastTemp's declared NoData sentinel is -999 itself, so writing -999
+	// as a real reading is indistinguishable from "no data" - the same
+	// ambiguity CAT-roller's own "?" sentinel has for any column whose
+	// valid range happens to include the sentinel value.
+	if !math.IsNaN(p0.Extra.LastTemp) {
+		t.Errorf("point 0: LastTemp = %v, want NaN (matches its own NoData sentinel)", p0.Extra.LastTemp)
+	}
+
+	p1 := points[1]
+	if p1.Extra == nil {
+		t.Fatal("point 1: Extra is nil, want a decoded all-NaN ExtraAttributes")
+	}
+	for _, v := range p1.Extra.values() {
+		if !math.IsNaN(v) {
+			t.Errorf("point 1 (no Extra set on write): got %v, want NaN for every field", v)
+		}
+	}
+}
+
+// TestExtraBytesVLRFieldDescriptors verifies the Extra Bytes VLR's data
+// payload parses back into one descriptor per CompactionExtraByteFields
+// entry, preserving name/scale/offset/no_data.
+func TestExtraBytesVLRFieldDescriptors(t *testing.T) {
+	vlr := extraBytesVLR()
+	if vlr.userID != "LASF_Spec" || vlr.recordID != 4 {
+		t.Fatalf("VLR userID/recordID = %q/%d, want LASF_Spec/4", vlr.userID, vlr.recordID)
+	}
+
+	descriptors, err := parseExtraByteVLRData(vlr.data)
+	if err != nil {
+		t.Fatalf("parseExtraByteVLRData failed: %v", err)
+	}
+	if len(descriptors) != len(CompactionExtraByteFields) {
+		t.Fatalf("got %d descriptors, want %d", len(descriptors), len(CompactionExtraByteFields))
+	}
+	for i, want := range CompactionExtraByteFields {
+		got := descriptors[i]
+		if got.Name != want.Name {
+			t.Errorf("descriptor %d: Name = %q, want %q", i, got.Name, want.Name)
+		}
+		if got.Scale != want.Scale || got.Offset != want.Offset || got.NoData != want.NoData {
+			t.Errorf("descriptor %d (%s): Scale/Offset/NoData = %v/%v/%v, want %v/%v/%v",
+				i, want.Name, got.Scale, got.Offset, got.NoData, want.Scale, want.Offset, want.NoData)
+		}
+	}
+}
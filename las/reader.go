@@ -1,11 +1,15 @@
 package las
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"math"
-	"os"
+	"path/filepath"
+	"strings"
+
+	"compactmapper/internal/fsutil"
 )
 
 // Header represents LAS file header information
@@ -15,21 +19,52 @@ type Header struct {
 	PointFormat       uint8
 	PointCount        uint32
 	PointRecordLength uint16
+	OffsetToPointData uint32
 	XScale, YScale, ZScale float64
 	XOffset, YOffset, ZOffset float64
 	MinX, MinY, MinZ float64
 	MaxX, MaxY, MaxZ float64
+	// HeaderSize is the on-disk header length: 227 for LAS 1.2, 375 for
+	// LAS 1.4.
+	HeaderSize uint16
+	// NumberOfVLRs is the count of Variable Length Records read
+	// immediately after the header.
+	NumberOfVLRs uint32
+	// StartOfFirstEVLR and NumberOfEVLRs locate the Extended Variable
+	// Length Records at the tail of a LAS 1.4 file. Both are zero for
+	// LAS 1.2 files, which have no EVLR support.
+	StartOfFirstEVLR uint64
+	NumberOfEVLRs    uint32
 }
 
 // Reader handles reading LAS files
 type Reader struct {
-	file   *os.File
+	file   fsutil.ReadSeekCloser
 	header Header
+	// extraFields is non-nil when the file carries an Extra Bytes VLR
+	// (user ID "LASF_Spec", record ID 4), in which case every point
+	// record is header.PointRecordLength bytes instead of the bare
+	// point-format length, and the trailing bytes decode per these
+	// field descriptors.
+	extraFields []extraByteFieldDescriptor
 }
 
-// NewReader creates a new LAS reader
+// NewReader creates a new LAS reader backed by the real filesystem.
 func NewReader(filename string) (*Reader, error) {
-	file, err := os.Open(filename)
+	return NewReaderFS(fsutil.OS, filename)
+}
+
+// NewReaderFS creates a new LAS reader that reads through fsys, letting
+// callers substitute an in-memory Filesystem in tests. A .laz filename is
+// transparently decompressed first - see readLAZFS.
+func NewReaderFS(fsys fsutil.Filesystem, filename string) (*Reader, error) {
+	var file fsutil.ReadSeekCloser
+	var err error
+	if strings.EqualFold(filepath.Ext(filename), ".laz") {
+		file, err = readLAZFS(fsys, filename)
+	} else {
+		file, err = fsys.Open(filename)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error opening file: %v", err)
 	}
@@ -41,6 +76,10 @@ func NewReader(filename string) (*Reader, error) {
 		file.Close()
 		return nil, err
 	}
+	if err := reader.readEVLRs(); err != nil {
+		file.Close()
+		return nil, err
+	}
 
 	return reader, nil
 }
@@ -50,46 +89,126 @@ func (r *Reader) Close() error {
 	return r.file.Close()
 }
 
-// readHeader reads and parses the LAS header
+// readHeader reads and parses the LAS header. The first 227 bytes are
+// laid out identically across LAS 1.2 and LAS 1.4 (the versions this
+// package writes); LAS 1.4 files carry an additional 148 bytes with
+// extended VLR/waveform offsets and 64-bit point counts, which are only
+// present when VersionMinor == 4.
 func (r *Reader) readHeader() error {
-	header := make([]byte, 227)
-	if _, err := io.ReadFull(r.file, header); err != nil {
+	base := make([]byte, 227)
+	if _, err := io.ReadFull(r.file, base); err != nil {
 		return fmt.Errorf("error reading header: %v", err)
 	}
 
 	// Verify signature
-	if string(header[0:4]) != "LASF" {
+	if string(base[0:4]) != "LASF" {
 		return fmt.Errorf("invalid LAS file: wrong signature")
 	}
 
-	// Parse version
-	r.header.VersionMajor = header[24]
-	r.header.VersionMinor = header[25]
+	r.header.VersionMajor = base[24]
+	r.header.VersionMinor = base[25]
+
+	r.header.HeaderSize = binary.LittleEndian.Uint16(base[94:96])
+	r.header.PointFormat = base[104]
+	r.header.PointRecordLength = binary.LittleEndian.Uint16(base[105:107])
+	r.header.PointCount = binary.LittleEndian.Uint32(base[107:111])
+	r.header.OffsetToPointData = binary.LittleEndian.Uint32(base[96:100])
+	numVLRs := binary.LittleEndian.Uint32(base[100:104])
+	r.header.NumberOfVLRs = numVLRs
+
+	r.header.XScale = math.Float64frombits(binary.LittleEndian.Uint64(base[131:139]))
+	r.header.YScale = math.Float64frombits(binary.LittleEndian.Uint64(base[139:147]))
+	r.header.ZScale = math.Float64frombits(binary.LittleEndian.Uint64(base[147:155]))
+
+	r.header.XOffset = math.Float64frombits(binary.LittleEndian.Uint64(base[155:163]))
+	r.header.YOffset = math.Float64frombits(binary.LittleEndian.Uint64(base[163:171]))
+	r.header.ZOffset = math.Float64frombits(binary.LittleEndian.Uint64(base[171:179]))
+
+	r.header.MaxX = math.Float64frombits(binary.LittleEndian.Uint64(base[179:187]))
+	r.header.MaxY = math.Float64frombits(binary.LittleEndian.Uint64(base[187:195]))
+	r.header.MaxZ = math.Float64frombits(binary.LittleEndian.Uint64(base[195:203]))
+
+	r.header.MinX = math.Float64frombits(binary.LittleEndian.Uint64(base[203:211]))
+	r.header.MinY = math.Float64frombits(binary.LittleEndian.Uint64(base[211:219]))
+	r.header.MinZ = math.Float64frombits(binary.LittleEndian.Uint64(base[219:227]))
+
+	if r.header.VersionMinor == 4 {
+		ext := make([]byte, 375-227)
+		if _, err := io.ReadFull(r.file, ext); err != nil {
+			return fmt.Errorf("error reading LAS 1.4 extended header: %v", err)
+		}
+		// ext[0:8] = start of waveform data packet record; unused here.
+		r.header.StartOfFirstEVLR = binary.LittleEndian.Uint64(ext[8:16])
+		r.header.NumberOfEVLRs = binary.LittleEndian.Uint32(ext[16:20])
+		pointCount64 := binary.LittleEndian.Uint64(ext[20:28])
+		if pointCount64 > 0 {
+			r.header.PointCount = uint32(pointCount64)
+		}
+	}
+
+	return r.readVLRs(numVLRs)
+}
 
-	// Parse point format and count
-	r.header.PointFormat = header[104]
-	r.header.PointRecordLength = binary.LittleEndian.Uint16(header[105:107])
-	r.header.PointCount = binary.LittleEndian.Uint32(header[107:111])
+// readEVLRs reads the Extended Variable Length Records at the tail of a
+// LAS 1.4 file (r.header.StartOfFirstEVLR, r.header.NumberOfEVLRs),
+// seeking there and leaving the file positioned after the last EVLR;
+// ReadPoints, ForEachPoint and PointsRange each seek to the point data
+// themselves. Like readVLRs, every EVLR's data is read and
+// discarded - this package has no EVLR payload it currently needs to
+// interpret (e.g. no waveform data packets), but callers can at least
+// confirm how many are present and that the file isn't truncated.
+func (r *Reader) readEVLRs() error {
+	if r.header.VersionMinor != 4 || r.header.NumberOfEVLRs == 0 {
+		return nil
+	}
 
-	// Parse scale factors
-	r.header.XScale = math.Float64frombits(binary.LittleEndian.Uint64(header[131:139]))
-	r.header.YScale = math.Float64frombits(binary.LittleEndian.Uint64(header[139:147]))
-	r.header.ZScale = math.Float64frombits(binary.LittleEndian.Uint64(header[147:155]))
+	if _, err := r.file.Seek(int64(r.header.StartOfFirstEVLR), 0); err != nil {
+		return fmt.Errorf("error seeking to EVLRs: %v", err)
+	}
 
-	// Parse offsets
-	r.header.XOffset = math.Float64frombits(binary.LittleEndian.Uint64(header[155:163]))
-	r.header.YOffset = math.Float64frombits(binary.LittleEndian.Uint64(header[163:171]))
-	r.header.ZOffset = math.Float64frombits(binary.LittleEndian.Uint64(header[171:179]))
+	for i := uint32(0); i < r.header.NumberOfEVLRs; i++ {
+		evlrHeader := make([]byte, 60)
+		if _, err := io.ReadFull(r.file, evlrHeader); err != nil {
+			return fmt.Errorf("error reading EVLR %d header: %v", i, err)
+		}
+		dataLength := binary.LittleEndian.Uint64(evlrHeader[20:28])
+		if _, err := r.file.Seek(int64(dataLength), 1); err != nil {
+			return fmt.Errorf("error skipping EVLR %d data: %v", i, err)
+		}
+	}
+	return nil
+}
 
-	// Parse bounds
-	r.header.MaxX = math.Float64frombits(binary.LittleEndian.Uint64(header[179:187]))
-	r.header.MaxY = math.Float64frombits(binary.LittleEndian.Uint64(header[187:195]))
-	r.header.MaxZ = math.Float64frombits(binary.LittleEndian.Uint64(header[195:203]))
+// readVLRs reads count Variable Length Records immediately following the
+// header (vlrRecord.bytes' 54-byte-header-plus-data layout) and, if one
+// is an Extra Bytes VLR (user ID "LASF_Spec", record ID 4), records its
+// field descriptors so ReadPoints can decode the trailing bytes every
+// point record carries. Any other VLR's data is read and discarded -
+// this package has no need to interpret CRS VLRs on read, only write
+// them.
+func (r *Reader) readVLRs(count uint32) error {
+	for i := uint32(0); i < count; i++ {
+		vlrHeader := make([]byte, 54)
+		if _, err := io.ReadFull(r.file, vlrHeader); err != nil {
+			return fmt.Errorf("error reading VLR %d header: %v", i, err)
+		}
+		userID := string(bytes.TrimRight(vlrHeader[2:18], "\x00"))
+		recordID := binary.LittleEndian.Uint16(vlrHeader[18:20])
+		dataLength := binary.LittleEndian.Uint16(vlrHeader[20:22])
 
-	r.header.MinX = math.Float64frombits(binary.LittleEndian.Uint64(header[203:211]))
-	r.header.MinY = math.Float64frombits(binary.LittleEndian.Uint64(header[211:219]))
-	r.header.MinZ = math.Float64frombits(binary.LittleEndian.Uint64(header[219:227]))
+		data := make([]byte, dataLength)
+		if _, err := io.ReadFull(r.file, data); err != nil {
+			return fmt.Errorf("error reading VLR %d data: %v", i, err)
+		}
 
+		if userID == vlrUserIDLASFSpec && recordID == recordIDExtraBytes {
+			descriptors, err := parseExtraByteVLRData(data)
+			if err != nil {
+				return fmt.Errorf("error parsing Extra Bytes VLR: %v", err)
+			}
+			r.extraFields = descriptors
+		}
+	}
 	return nil
 }
 
@@ -98,78 +217,261 @@ func (r *Reader) GetHeader() Header {
 	return r.header
 }
 
-// ReadPoints reads all points from the LAS file
-func (r *Reader) ReadPoints() ([]Point, error) {
-	// Seek to start of point data (after header)
-	if _, err := r.file.Seek(227, 0); err != nil {
-		return nil, fmt.Errorf("error seeking to point data: %v", err)
+// baseRecordLength returns the fixed (non-extra-bytes) byte length of one
+// point record in the given point data record format.
+func baseRecordLength(format uint8) (int, error) {
+	switch format {
+	case 2:
+		return 26, nil
+	case 3:
+		return 34, nil
+	case 6:
+		return 30, nil
+	case 7:
+		return 36, nil
+	case 8:
+		return 38, nil
+	default:
+		return 0, fmt.Errorf("unsupported point format: %d (supported: 2, 3, 6, 7, 8)", format)
+	}
+}
+
+// decodePointRecord decodes one point's fixed-length fields out of
+// pointData, which must be baseRecordLength(format) bytes. It's the
+// single field-layout authority shared by ReadPoints, ForEachPoint, and
+// PointsRange, so the three entry points can't drift apart on offsets.
+func (r *Reader) decodePointRecord(format uint8, pointData []byte) Point {
+	x := int32(binary.LittleEndian.Uint32(pointData[0:4]))
+	y := int32(binary.LittleEndian.Uint32(pointData[4:8]))
+	z := int32(binary.LittleEndian.Uint32(pointData[8:12]))
+
+	p := Point{
+		X:         float64(x)*r.header.XScale + r.header.XOffset,
+		Y:         float64(y)*r.header.YScale + r.header.YOffset,
+		Z:         float64(z)*r.header.ZScale + r.header.ZOffset,
+		Intensity: binary.LittleEndian.Uint16(pointData[12:14]),
 	}
 
-	switch r.header.PointFormat {
+	switch format {
 	case 2:
-		return r.readPointsFormat2()
+		p.Classification = pointData[15]
+		p.R = binary.LittleEndian.Uint16(pointData[20:22])
+		p.G = binary.LittleEndian.Uint16(pointData[22:24])
+		p.B = binary.LittleEndian.Uint16(pointData[24:26])
 	case 3:
-		return r.readPointsFormat3()
-	default:
-		return nil, fmt.Errorf("unsupported point format: %d (supported: 2, 3)", r.header.PointFormat)
+		p.Classification = pointData[15]
+		p.GPSTime = math.Float64frombits(binary.LittleEndian.Uint64(pointData[20:28]))
+		p.R = binary.LittleEndian.Uint16(pointData[28:30])
+		p.G = binary.LittleEndian.Uint16(pointData[30:32])
+		p.B = binary.LittleEndian.Uint16(pointData[32:34])
+	case 6:
+		p.Classification = pointData[16]
+		p.GPSTime = math.Float64frombits(binary.LittleEndian.Uint64(pointData[22:30]))
+	case 7:
+		p.Classification = pointData[16]
+		p.GPSTime = math.Float64frombits(binary.LittleEndian.Uint64(pointData[22:30]))
+		p.R = binary.LittleEndian.Uint16(pointData[30:32])
+		p.G = binary.LittleEndian.Uint16(pointData[32:34])
+		p.B = binary.LittleEndian.Uint16(pointData[34:36])
+	case 8:
+		p.Classification = pointData[16]
+		p.GPSTime = math.Float64frombits(binary.LittleEndian.Uint64(pointData[22:30]))
+		p.R = binary.LittleEndian.Uint16(pointData[30:32])
+		p.G = binary.LittleEndian.Uint16(pointData[32:34])
+		p.B = binary.LittleEndian.Uint16(pointData[34:36])
+		p.NIR = binary.LittleEndian.Uint16(pointData[36:38])
 	}
+
+	return p
 }
 
-// readPointsFormat2 reads Format 2 points (26 bytes: XYZ + Intensity + RGB)
-func (r *Reader) readPointsFormat2() ([]Point, error) {
-	points := make([]Point, 0, r.header.PointCount)
+// readOnePoint reads and decodes the next point record from r.file,
+// including any trailing extra bytes, using a single reusable buffer
+// sized for the current point format plus PointRecordLength's overhang.
+func (r *Reader) readOnePoint(buf []byte) (Point, error) {
+	baseLen, err := baseRecordLength(r.header.PointFormat)
+	if err != nil {
+		return Point{}, err
+	}
+	if _, err := io.ReadFull(r.file, buf[:baseLen]); err != nil {
+		return Point{}, fmt.Errorf("error reading point: %v", err)
+	}
+
+	p := r.decodePointRecord(r.header.PointFormat, buf[:baseLen])
 
-	for i := uint32(0); i < r.header.PointCount; i++ {
-		pointData := make([]byte, 26)
-		if _, err := io.ReadFull(r.file, pointData); err != nil {
-			return nil, fmt.Errorf("error reading point %d: %v", i, err)
-		}
+	extra, err := r.readExtraBytes(baseLen)
+	if err != nil {
+		return Point{}, err
+	}
+	p.Extra = extra
 
-		x := int32(binary.LittleEndian.Uint32(pointData[0:4]))
-		y := int32(binary.LittleEndian.Uint32(pointData[4:8]))
-		z := int32(binary.LittleEndian.Uint32(pointData[8:12]))
+	return p, nil
+}
 
-		points = append(points, Point{
-			X:              float64(x)*r.header.XScale + r.header.XOffset,
-			Y:              float64(y)*r.header.YScale + r.header.YOffset,
-			Z:              float64(z)*r.header.ZScale + r.header.ZOffset,
-			Intensity:      binary.LittleEndian.Uint16(pointData[12:14]),
-			Classification: pointData[15],
-			R:              binary.LittleEndian.Uint16(pointData[20:22]),
-			G:              binary.LittleEndian.Uint16(pointData[22:24]),
-			B:              binary.LittleEndian.Uint16(pointData[24:26]),
-		})
+// seekToPointData seeks the underlying file to the start of the point
+// data records, as recorded in the header (or the legacy fixed 227-byte
+// offset for files with no OffsetToPointData).
+func (r *Reader) seekToPointData() error {
+	offset := int64(r.header.OffsetToPointData)
+	if offset == 0 {
+		offset = 227
+	}
+	if _, err := r.file.Seek(offset, 0); err != nil {
+		return fmt.Errorf("error seeking to point data: %v", err)
+	}
+	return nil
+}
+
+// pointCapacityHint returns a safe capacity for ReadPoints' slice
+// pre-allocation: header.PointCount, clamped to however many
+// PointRecordLength-sized records can actually fit in what's left of the
+// file from the current (point-data-start) position. A malformed or
+// truncated header can claim far more points than the file could
+// possibly hold, and make([]Point, 0, N) with that untrusted N drives an
+// unbounded allocation before a single byte of point data is read; this
+// bounds N to what the file's real size can back up. It returns 0 (no
+// hint, just an empty slice) rather than erroring if the size can't be
+// determined - ReadPoints' read loop still reports any real problem.
+func (r *Reader) pointCapacityHint() uint32 {
+	if r.header.PointRecordLength == 0 {
+		return 0
+	}
+
+	cur, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	end, err := r.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+	if _, err := r.file.Seek(cur, io.SeekStart); err != nil {
+		return 0
+	}
+
+	remaining := end - cur
+	if remaining <= 0 {
+		return 0
+	}
+	maxRecords := uint64(remaining) / uint64(r.header.PointRecordLength)
+
+	want := uint64(r.header.PointCount)
+	if want > maxRecords {
+		return uint32(maxRecords)
+	}
+	return uint32(want)
+}
+
+// ReadPoints reads all points from the LAS file into memory. For large
+// files, prefer ForEachPoint or PointsRange, which don't buffer the
+// entire point set at once.
+func (r *Reader) ReadPoints() ([]Point, error) {
+	if _, err := baseRecordLength(r.header.PointFormat); err != nil {
+		return nil, err
+	}
+	if err := r.seekToPointData(); err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, r.pointCapacityHint())
+	buf := make([]byte, r.header.PointRecordLength)
+	for i := uint32(0); i < r.header.PointCount; i++ {
+		p, err := r.readOnePoint(buf)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %v", i, err)
+		}
+		points = append(points, p)
 	}
 
 	return points, nil
 }
 
-// readPointsFormat3 reads Format 3 points (34 bytes: XYZ + Intensity + GPS Time + RGB)
-func (r *Reader) readPointsFormat3() ([]Point, error) {
-	points := make([]Point, 0, r.header.PointCount)
+// ForEachPoint seeks to the point data once and decodes one record at a
+// time into a reusable buffer, calling fn for each point in order. It
+// stops and returns fn's error as soon as fn returns one, and is the
+// streaming alternative to ReadPoints for multi-million-point files that
+// shouldn't be buffered into a single slice.
+func (r *Reader) ForEachPoint(fn func(Point) error) error {
+	if _, err := baseRecordLength(r.header.PointFormat); err != nil {
+		return err
+	}
+	if err := r.seekToPointData(); err != nil {
+		return err
+	}
 
+	buf := make([]byte, r.header.PointRecordLength)
 	for i := uint32(0); i < r.header.PointCount; i++ {
-		pointData := make([]byte, 34)
-		if _, err := io.ReadFull(r.file, pointData); err != nil {
-			return nil, fmt.Errorf("error reading point %d: %v", i, err)
+		p, err := r.readOnePoint(buf)
+		if err != nil {
+			return fmt.Errorf("point %d: %v", i, err)
+		}
+		if err := fn(p); err != nil {
+			return err
 		}
+	}
 
-		x := int32(binary.LittleEndian.Uint32(pointData[0:4]))
-		y := int32(binary.LittleEndian.Uint32(pointData[4:8]))
-		z := int32(binary.LittleEndian.Uint32(pointData[8:12]))
+	return nil
+}
+
+// PointsRange reads the count points starting at index start, seeking
+// directly to that point's offset using the header's fixed
+// PointRecordLength rather than decoding (and discarding) every point
+// before it. It's meant for range-style access into a large file, e.g.
+// an HTTP handler serving one tile of points at a time.
+func (r *Reader) PointsRange(start, count uint32) ([]Point, error) {
+	if _, err := baseRecordLength(r.header.PointFormat); err != nil {
+		return nil, err
+	}
 
-		points = append(points, Point{
-			X:              float64(x)*r.header.XScale + r.header.XOffset,
-			Y:              float64(y)*r.header.YScale + r.header.YOffset,
-			Z:              float64(z)*r.header.ZScale + r.header.ZOffset,
-			Intensity:      binary.LittleEndian.Uint16(pointData[12:14]),
-			Classification: pointData[15],
-			GPSTime:        math.Float64frombits(binary.LittleEndian.Uint64(pointData[20:28])),
-			R:              binary.LittleEndian.Uint16(pointData[28:30]),
-			G:              binary.LittleEndian.Uint16(pointData[30:32]),
-			B:              binary.LittleEndian.Uint16(pointData[32:34]),
-		})
+	base := int64(r.header.OffsetToPointData)
+	if base == 0 {
+		base = 227
+	}
+	offset := base + int64(start)*int64(r.header.PointRecordLength)
+	if _, err := r.file.Seek(offset, 0); err != nil {
+		return nil, fmt.Errorf("error seeking to point %d: %v", start, err)
+	}
+
+	end := start + count
+	if end > r.header.PointCount {
+		end = r.header.PointCount
+	}
+	if start >= end {
+		return nil, nil
+	}
+
+	points := make([]Point, 0, end-start)
+	buf := make([]byte, r.header.PointRecordLength)
+	for i := start; i < end; i++ {
+		p, err := r.readOnePoint(buf)
+		if err != nil {
+			return nil, fmt.Errorf("point %d: %v", i, err)
+		}
+		points = append(points, p)
 	}
 
 	return points, nil
 }
+
+// readExtraBytes reads and, if r.extraFields was populated from the
+// file's Extra Bytes VLR, decodes the bytes trailing one point's fixed
+// baseLen-byte record - the gap between baseLen and
+// header.PointRecordLength that every point format's reader otherwise
+// leaves unconsumed. Returns nil (no error) when PointRecordLength
+// matches baseLen exactly, i.e. the file has no extra bytes at all.
+func (r *Reader) readExtraBytes(baseLen int) (*ExtraAttributes, error) {
+	extraLen := int(r.header.PointRecordLength) - baseLen
+	if extraLen <= 0 {
+		return nil, nil
+	}
+	data := make([]byte, extraLen)
+	if _, err := io.ReadFull(r.file, data); err != nil {
+		return nil, fmt.Errorf("error reading extra bytes: %v", err)
+	}
+	if len(r.extraFields) == 0 {
+		// A vendor-specific payload this package doesn't recognize;
+		// skip it rather than failing the whole read.
+		return nil, nil
+	}
+	return decodeExtraBytes(data, r.extraFields), nil
+}
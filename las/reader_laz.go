@@ -0,0 +1,57 @@
+package las
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"compactmapper/internal/fsutil"
+)
+
+// readLAZFS opens a .laz file for reading by decompressing it to a
+// temporary .las file and opening that instead. This mirrors WriteLAZ's
+// choice to delegate the actual LASzip codec to an external laszip/pdal
+// process rather than implementing chunked arithmetic coding in this
+// package - see WriteLAZ's doc comment for the full rationale, which
+// applies just as much to decoding: a subtly wrong hand-rolled decoder
+// would silently misread point data, which is worse than requiring one
+// of those tools on PATH.
+func readLAZFS(fsys fsutil.Filesystem, filename string) (fsutil.ReadSeekCloser, error) {
+	tool, args, tmpLAS, err := lazDecompressor(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(tool, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmpLAS)
+		return nil, fmt.Errorf("error running %s: %v\n%s", tool, err, out)
+	}
+
+	// Open before removing: on POSIX, an already-open file descriptor
+	// keeps working after its directory entry is unlinked, so the
+	// returned ReadSeekCloser is unaffected - this just stops tmpLAS
+	// from lingering as a stray ".las" file every later *.las glob in
+	// the tree (preview server's file listing, the bundler's layout
+	// check) would otherwise pick up.
+	file, err := fsys.Open(tmpLAS)
+	os.Remove(tmpLAS)
+	if err != nil {
+		return nil, fmt.Errorf("error opening decompressed LAS file: %v", err)
+	}
+	return file, nil
+}
+
+// lazDecompressor picks the external tool used to decompress filename (a
+// .laz file) into a sibling ".tmp.las" file, preferring laszip (the
+// reference LASzip CLI) and falling back to pdal translate.
+func lazDecompressor(filename string) (tool string, args []string, tmpLAS string, err error) {
+	tmpLAS = filename + ".tmp.las"
+	if _, lookErr := exec.LookPath("laszip"); lookErr == nil {
+		return "laszip", []string{"-i", filename, "-o", tmpLAS}, tmpLAS, nil
+	}
+	if _, lookErr := exec.LookPath("pdal"); lookErr == nil {
+		return "pdal", []string{"translate", filename, tmpLAS}, tmpLAS, nil
+	}
+	return "", nil, "", fmt.Errorf("LAZ input requires laszip or pdal on PATH; neither was found")
+}
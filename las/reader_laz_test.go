@@ -0,0 +1,22 @@
+package las
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestNewReaderFSLAZWithoutToolsFails verifies NewReaderFS surfaces a
+// clear error for a .laz filename when neither laszip nor pdal is on
+// PATH, rather than trying (and failing) to parse the compressed bytes
+// as a plain LAS file.
+func TestNewReaderFSLAZWithoutToolsFails(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tmpDir := t.TempDir()
+	lazFile := filepath.Join(tmpDir, "test.laz")
+
+	_, err := NewReader(lazFile)
+	if err == nil {
+		t.Fatal("expected an error when no LAZ decompressor is available, got nil")
+	}
+}
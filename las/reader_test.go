@@ -0,0 +1,141 @@
+package las
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func writePointsForReaderTest(t *testing.T, points []Point) string {
+	t.Helper()
+	lasFile := filepath.Join(t.TempDir(), "test.las")
+	writer := NewWriter()
+	for _, p := range points {
+		writer.AddPoint(p)
+	}
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return lasFile
+}
+
+func testPointsForReader() []Point {
+	return []Point{
+		{X: 1, Y: 10, Z: 100, R: 1000},
+		{X: 2, Y: 20, Z: 200, R: 2000},
+		{X: 3, Y: 30, Z: 300, R: 3000},
+		{X: 4, Y: 40, Z: 400, R: 4000},
+		{X: 5, Y: 50, Z: 500, R: 5000},
+	}
+}
+
+func TestForEachPointMatchesReadPoints(t *testing.T) {
+	lasFile := writePointsForReaderTest(t, testPointsForReader())
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	want, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+
+	reader2, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader2.Close()
+
+	var got []Point
+	if err := reader2.ForEachPoint(func(p Point) error {
+		got = append(got, p)
+		return nil
+	}); err != nil {
+		t.Fatalf("ForEachPoint failed: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("ForEachPoint yielded %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].X != want[i].X || got[i].Y != want[i].Y || got[i].Z != want[i].Z || got[i].R != want[i].R {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestForEachPointStopsOnError(t *testing.T) {
+	lasFile := writePointsForReaderTest(t, testPointsForReader())
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	stopErr := errStop
+	seen := 0
+	err = reader.ForEachPoint(func(p Point) error {
+		seen++
+		if seen == 2 {
+			return stopErr
+		}
+		return nil
+	})
+
+	if err != stopErr {
+		t.Fatalf("ForEachPoint error = %v, want %v", err, stopErr)
+	}
+	if seen != 2 {
+		t.Errorf("ForEachPoint called fn %d times, want 2", seen)
+	}
+}
+
+func TestPointsRange(t *testing.T) {
+	points := testPointsForReader()
+	lasFile := writePointsForReaderTest(t, points)
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := reader.PointsRange(1, 2)
+	if err != nil {
+		t.Fatalf("PointsRange failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("PointsRange returned %d points, want 2", len(got))
+	}
+	if got[0].X != points[1].X || got[1].X != points[2].X {
+		t.Errorf("PointsRange(1, 2) = %+v, want points[1:3]", got)
+	}
+}
+
+func TestPointsRangeClampsToEnd(t *testing.T) {
+	points := testPointsForReader()
+	lasFile := writePointsForReaderTest(t, points)
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	got, err := reader.PointsRange(3, 100)
+	if err != nil {
+		t.Fatalf("PointsRange failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("PointsRange(3, 100) returned %d points, want 2 (clamped to point count)", len(got))
+	}
+}
+
+type stopError struct{}
+
+func (stopError) Error() string { return "stop" }
+
+var errStop error = stopError{}
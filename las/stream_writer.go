@@ -0,0 +1,197 @@
+package las
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamWriter writes a LAS 1.2, point format 3 file one point at a time,
+// without buffering the point slice in memory the way Writer does. It
+// writes directly to an io.WriteSeeker: a placeholder header is written
+// first, each AddPoint call encodes and writes its record immediately,
+// and Close seeks back to patch the header fields (point count, bounds,
+// points-by-return) that can only be known once every point has been
+// seen.
+//
+// Because the scale/offset used to encode a point's XYZ would normally
+// be derived from the minimum coordinate across all points - which isn't
+// known until the last point has arrived - StreamWriter instead anchors
+// its offset to the first point it sees (offsetX/Y/Z below), fixing that
+// as the origin for every point in the file at the same 0.001 scale
+// Writer uses. A fixed offset of (0, 0, 0), which this package used to
+// use, overflows the int32 a point format 3 record encodes X/Y/Z into
+// for any realistic UTM northing (they run 0-10,000,000 m, while 0.001
+// scale only covers +-2,147,483.647 m from the offset); anchoring on the
+// first point instead keeps every point within range as long as the
+// whole file's points stay within maxStreamCoordSpan of each other, which
+// any single real jobsite comfortably does. AddPoint returns an error
+// rather than silently wrapping if a point falls outside that span. It's
+// transparent to readers: the LAS Reader already reads XOffset/YOffset/
+// ZOffset from the header rather than assuming offset == min.
+//
+// StreamWriter only covers the LAS 1.2 / point format 3 case. It doesn't
+// support LAS 1.4, other point formats, CRS tagging, or LAZ compression -
+// see Writer's doc comment for why those don't fit a true single-pass
+// writer.
+type StreamWriter struct {
+	w io.WriteSeeker
+
+	count                     uint64
+	minX, minY, minZ          float64
+	maxX, maxY, maxZ          float64
+	offsetSet                 bool
+	offsetX, offsetY, offsetZ float64
+	closed                    bool
+}
+
+const streamWriterHeaderSize = 227
+
+// streamWriterScale is the fixed XYZ scale factor StreamWriter encodes
+// every point at - see StreamWriter's doc comment.
+const streamWriterScale = 0.001
+
+// maxStreamCoordSpan bounds how far a point's X, Y, or Z may fall from
+// StreamWriter's fixed offset before it would overflow the int32 field
+// format 3 encodes coordinates into at streamWriterScale. It's set
+// comfortably under the exact limit (float64(math.MaxInt32) * 0.001 =~
+// 2,147,483.647 m) to leave headroom for floating-point rounding.
+const maxStreamCoordSpan = 2_000_000.0
+
+// NewStreamWriter creates a StreamWriter that writes a LAS 1.2, point
+// format 3 file to w, writing a placeholder header immediately so point
+// data can follow it right away.
+func NewStreamWriter(w io.WriteSeeker) (*StreamWriter, error) {
+	sw := &StreamWriter{
+		w:    w,
+		minX: math.MaxFloat64, minY: math.MaxFloat64, minZ: math.MaxFloat64,
+		maxX: -math.MaxFloat64, maxY: -math.MaxFloat64, maxZ: -math.MaxFloat64,
+	}
+
+	if _, err := w.Write(make([]byte, streamWriterHeaderSize)); err != nil {
+		return nil, fmt.Errorf("error writing placeholder LAS header: %w", err)
+	}
+
+	return sw, nil
+}
+
+// AddPoint encodes p and writes it immediately, updating the running
+// bounds and count that Close will use to patch the header. The first
+// point AddPoint ever sees fixes the writer's X/Y/Z offset (see
+// StreamWriter's doc comment); every later point is checked against
+// maxStreamCoordSpan of that offset and rejected with an error instead
+// of being silently written as a wrapped, corrupt coordinate.
+func (sw *StreamWriter) AddPoint(p Point) error {
+	if !sw.offsetSet {
+		sw.offsetX, sw.offsetY, sw.offsetZ = p.X, p.Y, p.Z
+		sw.offsetSet = true
+	}
+	if err := checkStreamCoordSpan("X", p.X, sw.offsetX); err != nil {
+		return err
+	}
+	if err := checkStreamCoordSpan("Y", p.Y, sw.offsetY); err != nil {
+		return err
+	}
+	if err := checkStreamCoordSpan("Z", p.Z, sw.offsetZ); err != nil {
+		return err
+	}
+
+	if p.X < sw.minX { sw.minX = p.X }
+	if p.Y < sw.minY { sw.minY = p.Y }
+	if p.Z < sw.minZ { sw.minZ = p.Z }
+	if p.X > sw.maxX { sw.maxX = p.X }
+	if p.Y > sw.maxY { sw.maxY = p.Y }
+	if p.Z > sw.maxZ { sw.maxZ = p.Z }
+	sw.count++
+
+	pointData := encodePointFormat3(p, sw.offsetX, sw.offsetY, sw.offsetZ, streamWriterScale, streamWriterScale, streamWriterScale)
+	if _, err := sw.w.Write(pointData); err != nil {
+		return fmt.Errorf("error writing point record: %w", err)
+	}
+	return nil
+}
+
+// checkStreamCoordSpan reports an error if v is further than
+// maxStreamCoordSpan from offset - the point at which it would overflow
+// the int32 field format 3 encodes coordinates into - naming which axis
+// failed so the caller can see which coordinate is implausible.
+func checkStreamCoordSpan(axis string, v, offset float64) error {
+	if d := v - offset; d > maxStreamCoordSpan || d < -maxStreamCoordSpan {
+		return fmt.Errorf("point %s=%g is more than %g m from the first point written (%g): StreamWriter can't encode a span that wide at %g m scale", axis, v, maxStreamCoordSpan, offset, streamWriterScale)
+	}
+	return nil
+}
+
+// Close seeks back to the start of the file and writes the real header -
+// version, point format, record count, points-by-return, and bounds -
+// now that every point has been seen. It does not close the underlying
+// io.WriteSeeker; callers that opened it (e.g. an *os.File) are
+// responsible for closing it themselves.
+func (sw *StreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+
+	if sw.count == 0 {
+		return fmt.Errorf("no points to write")
+	}
+
+	header := sw.buildHeader()
+
+	if _, err := sw.w.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error seeking to patch LAS header: %w", err)
+	}
+	if _, err := sw.w.Write(header); err != nil {
+		return fmt.Errorf("error writing LAS header: %w", err)
+	}
+	return nil
+}
+
+// buildHeader builds the final 227-byte LAS 1.2 / point format 3 header,
+// mirroring Writer.buildHeader12 but sourced from StreamWriter's running
+// totals instead of a Writer's in-memory point slice.
+func (sw *StreamWriter) buildHeader() []byte {
+	header := make([]byte, streamWriterHeaderSize)
+
+	copy(header[0:4], []byte("LASF"))
+	binary.LittleEndian.PutUint16(header[4:6], 0)
+	binary.LittleEndian.PutUint16(header[6:8], 1) // Adjusted Standard GPS Time
+
+	header[24] = 1 // version major
+	header[25] = 2 // version minor
+
+	copy(header[26:58], []byte("CompactMapper"))
+	copy(header[58:90], []byte("CompactMapper v1.0"))
+
+	binary.LittleEndian.PutUint16(header[94:96], streamWriterHeaderSize)
+	binary.LittleEndian.PutUint32(header[96:100], uint32(streamWriterHeaderSize))
+
+	// Number of Variable Length Records
+	binary.LittleEndian.PutUint32(header[100:104], 0)
+
+	header[104] = 3 // point format
+	binary.LittleEndian.PutUint16(header[105:107], 34)
+
+	binary.LittleEndian.PutUint32(header[107:111], uint32(sw.count))
+	binary.LittleEndian.PutUint32(header[111:115], uint32(sw.count))
+
+	binary.LittleEndian.PutUint64(header[131:139], math.Float64bits(streamWriterScale))
+	binary.LittleEndian.PutUint64(header[139:147], math.Float64bits(streamWriterScale))
+	binary.LittleEndian.PutUint64(header[147:155], math.Float64bits(streamWriterScale))
+
+	binary.LittleEndian.PutUint64(header[155:163], math.Float64bits(sw.offsetX))
+	binary.LittleEndian.PutUint64(header[163:171], math.Float64bits(sw.offsetY))
+	binary.LittleEndian.PutUint64(header[171:179], math.Float64bits(sw.offsetZ))
+
+	binary.LittleEndian.PutUint64(header[179:187], math.Float64bits(sw.maxX))
+	binary.LittleEndian.PutUint64(header[187:195], math.Float64bits(sw.maxY))
+	binary.LittleEndian.PutUint64(header[195:203], math.Float64bits(sw.maxZ))
+
+	binary.LittleEndian.PutUint64(header[203:211], math.Float64bits(sw.minX))
+	binary.LittleEndian.PutUint64(header[211:219], math.Float64bits(sw.minY))
+	binary.LittleEndian.PutUint64(header[219:227], math.Float64bits(sw.minZ))
+
+	return header
+}
@@ -0,0 +1,130 @@
+package las
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStreamWriterRoundTrip writes a batch of points through StreamWriter
+// to a real file and confirms the existing Reader reads them back
+// correctly, proving the patched-at-Close header is valid.
+func TestStreamWriterRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "stream.las")
+
+	testPoints := []Point{
+		{X: 500000.1, Y: 4500000.2, Z: 120.5, R: 65535, G: 0, B: 0, Intensity: 100, Classification: 1, GPSTime: 1727776202.800},
+		{X: 500000.4, Y: 4500000.5, Z: 120.1, R: 0, G: 65535, B: 0, Intensity: 150, Classification: 2, GPSTime: 1727776203.000},
+		{X: 499999.9, Y: 4499999.8, Z: 121.3, R: 0, G: 0, B: 65535, Intensity: 200, Classification: 3, GPSTime: 1727776203.200},
+	}
+
+	f, err := os.Create(lasFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	sw, err := NewStreamWriter(f)
+	if err != nil {
+		t.Fatalf("NewStreamWriter failed: %v", err)
+	}
+	for _, pt := range testPoints {
+		if err := sw.AddPoint(pt); err != nil {
+			t.Fatalf("AddPoint failed: %v", err)
+		}
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("file Close failed: %v", err)
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	header := reader.GetHeader()
+	if header.VersionMajor != 1 || header.VersionMinor != 2 {
+		t.Errorf("version = %d.%d, want 1.2", header.VersionMajor, header.VersionMinor)
+	}
+	if header.PointFormat != 3 {
+		t.Errorf("PointFormat = %d, want 3", header.PointFormat)
+	}
+	if header.PointCount != uint32(len(testPoints)) {
+		t.Errorf("PointCount = %d, want %d", header.PointCount, len(testPoints))
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != len(testPoints) {
+		t.Fatalf("got %d points, want %d", len(points), len(testPoints))
+	}
+
+	const tolerance = 0.01
+	for i, want := range testPoints {
+		got := points[i]
+		if math.Abs(got.X-want.X) > tolerance || math.Abs(got.Y-want.Y) > tolerance || math.Abs(got.Z-want.Z) > tolerance {
+			t.Errorf("point %d XYZ = (%f, %f, %f), want (%f, %f, %f)", i, got.X, got.Y, got.Z, want.X, want.Y, want.Z)
+		}
+		if got.R != want.R || got.G != want.G || got.B != want.B {
+			t.Errorf("point %d RGB = (%d, %d, %d), want (%d, %d, %d)", i, got.R, got.G, got.B, want.R, want.G, want.B)
+		}
+	}
+}
+
+// TestStreamWriterRejectsPointTooFarFromOffset confirms AddPoint returns
+// an error instead of silently wrapping a coordinate that would overflow
+// the int32 field format 3 encodes X/Y/Z into - the bug a fixed (0, 0, 0)
+// offset used to hit for any real UTM northing.
+func TestStreamWriterRejectsPointTooFarFromOffset(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "toofar.las")
+
+	f, err := os.Create(lasFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+
+	sw, err := NewStreamWriter(f)
+	if err != nil {
+		t.Fatalf("NewStreamWriter failed: %v", err)
+	}
+
+	if err := sw.AddPoint(Point{X: 500000, Y: 4500000, Z: 100}); err != nil {
+		t.Fatalf("first AddPoint failed: %v", err)
+	}
+
+	if err := sw.AddPoint(Point{X: 500000 + maxStreamCoordSpan*2, Y: 4500000, Z: 100}); err == nil {
+		t.Error("expected an error for a point far outside maxStreamCoordSpan of the first point, got nil")
+	}
+}
+
+// TestStreamWriterNoPoints confirms Close rejects an empty stream rather
+// than emitting a header describing zero points - matching Writer's
+// "no points to write" behavior for an empty AddPoint history.
+func TestStreamWriterNoPoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "empty.las")
+
+	f, err := os.Create(lasFile)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+
+	sw, err := NewStreamWriter(f)
+	if err != nil {
+		t.Fatalf("NewStreamWriter failed: %v", err)
+	}
+
+	if err := sw.Close(); err == nil {
+		t.Error("Close with no points: got nil error, want an error")
+	}
+}
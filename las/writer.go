@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"compactmapper/internal/fsutil"
 )
 
 // Point represents a LAS point with RGB color and GPS Time
@@ -13,26 +18,103 @@ type Point struct {
 	X, Y, Z    float64
 	Intensity  uint16
 	R, G, B    uint16
+	// Classification holds the extended (0-255) classification code used
+	// by formats 6-8. This package doesn't split out the format 6-8
+	// ScanChannel/ClassificationFlags sub-fields the spec packs alongside
+	// it (scanner channel, synthetic/key-point/withheld/overlap bits):
+	// nothing here reads or writes them, and adding a representation
+	// would ripple through ColorMapper and the X3D preview renderer for
+	// no current benefit.
 	Classification uint8
 	GPSTime    float64 // GPS time in seconds (Adjusted Standard GPS Time)
+	// NIR is the near-infrared band carried by point format 8; it's
+	// ignored for every other format. Zero is indistinguishable from "no
+	// NIR channel" and "NIR value 0", matching how this package already
+	// treats Intensity/R/G/B.
+	NIR uint16
+	// Extra carries the compaction telemetry fields (LastCMV, TargCMV,
+	// ...) written alongside the point when Writer.SetExtraBytes(true).
+	// It's ignored unless extra bytes are enabled, and nil is equivalent
+	// to an ExtraAttributes with every field NaN ("no data").
+	Extra *ExtraAttributes
+}
+
+// Version identifies a LAS spec revision, e.g. {1, 2} or {1, 4}.
+type Version struct {
+	Major, Minor uint8
 }
 
-// Writer handles LAS file creation
+// Writer handles LAS file creation, buffering every point in memory so
+// it can compute a per-file coordinate offset (the minimum XYZ across
+// all points) and support LAS 1.4, CRS tagging, and LAZ compression -
+// none of which can be decided before the full point set is known. For
+// large files where buffering every point is too costly, see
+// StreamWriter, which writes each point immediately at the cost of
+// supporting only LAS 1.2 / point format 3 and an offset anchored to the
+// first point written.
 type Writer struct {
-	points []Point
+	fs          fsutil.Filesystem
+	points      []Point
+	version     Version
+	pointFormat uint8
+	crs         CRS
+	compression Compression
+	extraBytes  bool
 	minX, minY, minZ float64
 	maxX, maxY, maxZ float64
 }
 
-// NewWriter creates a new LAS writer
+// NewWriter creates a new LAS writer backed by the real filesystem,
+// defaulting to LAS 1.2, point format 3.
 func NewWriter() *Writer {
+	return NewWriterFS(fsutil.OS)
+}
+
+// NewWriterFS creates a new LAS writer that writes through fsys, letting
+// callers substitute an in-memory Filesystem in tests. It defaults to
+// LAS 1.2, point format 3; call SetFormat to target LAS 1.4.
+func NewWriterFS(fsys fsutil.Filesystem) *Writer {
 	return &Writer{
-		points: make([]Point, 0),
+		fs:          fsys,
+		points:      make([]Point, 0),
+		version:     Version{1, 2},
+		pointFormat: 3,
 		minX: math.MaxFloat64, minY: math.MaxFloat64, minZ: math.MaxFloat64,
 		maxX: -math.MaxFloat64, maxY: -math.MaxFloat64, maxZ: -math.MaxFloat64,
 	}
 }
 
+// SetFormat selects the LAS version and point data record format to
+// write. Supported combinations are LAS 1.2 with point formats 2 or 3,
+// and LAS 1.4 with point formats 6, 7, or 8 - the modern "64-bit point
+// count, extended classification" formats that tools like QGIS 3.x,
+// PDAL, and CloudCompare expect instead of legacy format 3, plus format
+// 8's near-infrared band for multispectral sensors. Waveform formats 9
+// and 10 are not supported; see encodePointFormat8's doc comment for why.
+func (w *Writer) SetFormat(version Version, pointFormat uint8) error {
+	switch {
+	case version == Version{1, 2} && (pointFormat == 2 || pointFormat == 3):
+	case version == Version{1, 4} && (pointFormat == 6 || pointFormat == 7 || pointFormat == 8):
+	default:
+		return fmt.Errorf("unsupported LAS %d.%d point format %d", version.Major, version.Minor, pointFormat)
+	}
+	w.version = version
+	w.pointFormat = pointFormat
+	return nil
+}
+
+// SetExtraBytes tags subsequent Write/WriteLAZ calls with an Extra Bytes
+// VLR (user ID "LASF_Spec", record ID 4) describing CompactionExtraByteFields,
+// and appends each point's Point.Extra as a scaled-int32 payload after its
+// regular point record. It works with any point format this package
+// supports, but is intended to pair with LAS 1.4 formats 6/7: formats 2/3
+// have no spare room for compaction telemetry, which is why CAT CMV/MDP/
+// EVIB values otherwise get packed lossily into RGB (see
+// converter.PassCountClassifier).
+func (w *Writer) SetExtraBytes(enabled bool) {
+	w.extraBytes = enabled
+}
+
 // AddPoint adds a point to the writer
 func (w *Writer) AddPoint(p Point) {
 	w.points = append(w.points, p)
@@ -46,13 +128,24 @@ func (w *Writer) AddPoint(p Point) {
 	if p.Z > w.maxZ { w.maxZ = p.Z }
 }
 
-// Write writes the LAS file to disk
-func (w *Writer) Write(filename string) (retErr error) {
+// Write writes the point cloud to filename, as a plain LAS file or, if
+// SetCompression(CompressionLAZ) was called, by delegating to WriteLAZ.
+func (w *Writer) Write(filename string) error {
+	if w.compression == CompressionLAZ {
+		return w.WriteLAZ(filename)
+	}
+	return w.writeUncompressed(filename)
+}
+
+// writeUncompressed does the actual LAS header/VLR/point encoding; both
+// Write (compression == CompressionNone) and WriteLAZ (which writes an
+// uncompressed file first, then compresses it) funnel through here.
+func (w *Writer) writeUncompressed(filename string) (retErr error) {
 	if len(w.points) == 0 {
 		return fmt.Errorf("no points to write")
 	}
 
-	file, err := os.Create(filename)
+	file, err := w.fs.Create(filename)
 	if err != nil {
 		return err
 	}
@@ -65,10 +158,224 @@ func (w *Writer) Write(filename string) (retErr error) {
 		}
 	}()
 
-	// LAS 1.2 Header (227 bytes)
+	vlrs := crsVLRs(w.crs)
+	if w.extraBytes {
+		vlrs = append(vlrs, extraBytesVLR())
+	}
+	var vlrBytes []byte
+	for _, v := range vlrs {
+		vlrBytes = append(vlrBytes, v.bytes()...)
+	}
+
+	var header []byte
+	if w.version.Minor == 4 {
+		header = w.buildHeader14()
+	} else {
+		header = w.buildHeader12()
+	}
+	if len(vlrs) > 0 {
+		headerSize := uint16(len(header))
+		binary.LittleEndian.PutUint32(header[96:100], uint32(headerSize)+uint32(len(vlrBytes)))
+		binary.LittleEndian.PutUint32(header[100:104], uint32(len(vlrs)))
+	}
+
+	if _, err := file.Write(header); err != nil {
+		return err
+	}
+	if len(vlrBytes) > 0 {
+		if _, err := file.Write(vlrBytes); err != nil {
+			return err
+		}
+	}
+
+	if !w.crs.isZero() {
+		if err := w.writePRJSidecar(filename); err != nil {
+			return err
+		}
+	}
+
+	xScale, yScale, zScale := 0.001, 0.001, 0.001
+	for _, p := range w.points {
+		var pointData []byte
+		switch w.pointFormat {
+		case 2:
+			pointData = encodePointFormat2(p, w.minX, w.minY, w.minZ, xScale, yScale, zScale)
+		case 6:
+			pointData = encodePointFormat6(p, w.minX, w.minY, w.minZ, xScale, yScale, zScale)
+		case 7:
+			pointData = encodePointFormat7(p, w.minX, w.minY, w.minZ, xScale, yScale, zScale)
+		case 8:
+			pointData = encodePointFormat8(p, w.minX, w.minY, w.minZ, xScale, yScale, zScale)
+		default:
+			pointData = encodePointFormat3(p, w.minX, w.minY, w.minZ, xScale, yScale, zScale)
+		}
+
+		if _, err := file.Write(pointData); err != nil {
+			return err
+		}
+		if w.extraBytes {
+			if _, err := file.Write(encodeExtraBytes(p.Extra)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writePRJSidecar writes a .prj file next to filename containing the
+// WKT definition of w.crs, for GIS tools (and older viewers) that read a
+// sidecar .prj instead of the file's GeoKeyDirectoryTag/WKT VLRs. When no
+// WKT is available for the configured EPSG code (outside the bundled
+// EPSGWKT table and no explicit CRS.WKT given), a bare "EPSG:<code>"
+// line is written instead - not a real .prj format, but better than
+// silently omitting the sidecar the caller asked for.
+func (w *Writer) writePRJSidecar(filename string) error {
+	ext := filepath.Ext(filename)
+	prjPath := strings.TrimSuffix(filename, ext) + ".prj"
+
+	content := w.crs.wkt()
+	if content == "" {
+		content = fmt.Sprintf("EPSG:%d", w.crs.EPSG)
+	}
+
+	prjFile, err := w.fs.Create(prjPath)
+	if err != nil {
+		return fmt.Errorf("error creating .prj sidecar: %w", err)
+	}
+	defer prjFile.Close()
+
+	if _, err := prjFile.Write([]byte(content)); err != nil {
+		return fmt.Errorf("error writing .prj sidecar: %w", err)
+	}
+	return nil
+}
+
+// WriteLAZ writes the current points as a compressed LAZ file.
+//
+// A true LASzip stream is chunked arithmetic-coded point records (an
+// integer compressor predicting XYZ from the previous point, byte
+// compressors for intensity/classification/RGB, a dedicated GPS-time
+// compressor) plus a chunk table and LASzip VLR describing it - a
+// nontrivial entropy coder that would be very easy to get subtly wrong
+// with no reference decoder in this sandbox to validate against, and a
+// silently-corrupt LAZ file is worse than no LAZ support at all. So
+// compression is delegated to whichever of the laszip or pdal
+// command-line tools is available on PATH instead: the points are first
+// written to an uncompressed temporary LAS file (via writeUncompressed,
+// carrying over CRS/GeoKey VLRs and point format exactly as Write would),
+// which the tool then compresses into filename, producing a real,
+// spec-exact LASzip stream. With a CRS set, the .prj sidecar is written
+// beside filename. An error naming both tools is returned if neither is
+// installed.
+func (w *Writer) WriteLAZ(filename string) error {
+	tool, args, err := lazCompressor(filename)
+	if err != nil {
+		return err
+	}
+
+	tmpLAS := filename + ".tmp.las"
+	if err := w.writeUncompressed(tmpLAS); err != nil {
+		return fmt.Errorf("error writing intermediate LAS file: %v", err)
+	}
+	defer os.Remove(tmpLAS)
+	if !w.crs.isZero() {
+		// writeUncompressed put its .prj beside tmpLAS, not filename.
+		defer os.Remove(strings.TrimSuffix(tmpLAS, ".las") + ".prj")
+	}
+
+	cmd := exec.Command(tool, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error running %s: %v\n%s", tool, err, out)
+	}
+
+	if !w.crs.isZero() {
+		return w.writePRJSidecar(filename)
+	}
+	return nil
+}
+
+// lazCompressor picks the external tool used to compress tmpLAS (= filename
+// with ".tmp.las" appended by WriteLAZ) into filename, preferring laszip
+// (the reference LASzip CLI) and falling back to pdal translate.
+func lazCompressor(filename string) (tool string, args []string, err error) {
+	tmpLAS := filename + ".tmp.las"
+	if _, lookErr := exec.LookPath("laszip"); lookErr == nil {
+		return "laszip", []string{"-i", tmpLAS, "-o", filename}, nil
+	}
+	if _, lookErr := exec.LookPath("pdal"); lookErr == nil {
+		return "pdal", []string{"translate", tmpLAS, filename}, nil
+	}
+	return "", nil, fmt.Errorf("LAZ output requires laszip or pdal on PATH; neither was found")
+}
+
+// buildHeader12 builds a LAS 1.2 header (227 bytes).
+func (w *Writer) buildHeader12() []byte {
 	header := make([]byte, 227)
 
-	// File signature "LASF"
+	recordLength := uint16(34)
+	if w.pointFormat == 2 {
+		recordLength = 26
+	}
+	recordLength += w.extraBytesRecordLength()
+	w.writeCommonHeaderFields(header, 227, recordLength)
+
+	// Number of point records (legacy, 32-bit)
+	binary.LittleEndian.PutUint32(header[107:111], uint32(len(w.points)))
+	// Number of points by return (5 fields); all points in return 1
+	binary.LittleEndian.PutUint32(header[111:115], uint32(len(w.points)))
+
+	w.writeScaleOffsetBounds(header, 0.001, 0.001, 0.001)
+
+	return header
+}
+
+// buildHeader14 builds a LAS 1.4 header (375 bytes), which replaces the
+// 32-bit legacy point counts with 64-bit counts so files with more than
+// ~4 billion points (or any point type using formats 6-10) are valid.
+func (w *Writer) buildHeader14() []byte {
+	header := make([]byte, 375)
+
+	recordLength := uint16(30)
+	if w.pointFormat == 7 {
+		recordLength = 36
+	} else if w.pointFormat == 8 {
+		recordLength = 38
+	}
+	recordLength += w.extraBytesRecordLength()
+	w.writeCommonHeaderFields(header, 375, recordLength)
+
+	// Legacy 32-bit point count fields are left at 0 for formats >= 6,
+	// per spec; readers must use the 64-bit fields below instead.
+
+	// Start of Waveform Data Packet record / first Extended VLR: none.
+	binary.LittleEndian.PutUint64(header[227:235], 0)
+	binary.LittleEndian.PutUint64(header[235:243], 0)
+	binary.LittleEndian.PutUint32(header[243:247], 0)
+
+	// Number of point records (64-bit)
+	binary.LittleEndian.PutUint64(header[247:255], uint64(len(w.points)))
+	// Number of points by return (15 fields); all points in return 1
+	binary.LittleEndian.PutUint64(header[255:263], uint64(len(w.points)))
+
+	w.writeScaleOffsetBounds(header, 0.001, 0.001, 0.001)
+
+	return header
+}
+
+// extraBytesRecordLength is the number of bytes CompactionExtraByteFields
+// adds to every point record when extra bytes are enabled, or 0.
+func (w *Writer) extraBytesRecordLength() uint16 {
+	if !w.extraBytes {
+		return 0
+	}
+	return uint16(4 * len(CompactionExtraByteFields))
+}
+
+// writeCommonHeaderFields fills in the header bytes shared by every LAS
+// version: signature, version, identifiers, and the point data layout
+// fields up through byte 107 (where the 1.2 and 1.4 layouts diverge).
+func (w *Writer) writeCommonHeaderFields(header []byte, headerSize uint16, recordLength uint16) {
 	copy(header[0:4], []byte("LASF"))
 
 	// File source ID
@@ -77,118 +384,150 @@ func (w *Writer) Write(filename string) (retErr error) {
 	// Global encoding (GPS Time Type: 1 = Adjusted Standard GPS Time)
 	binary.LittleEndian.PutUint16(header[6:8], 1)
 
-	// Project ID (GUID) - zeros
-	// header[8:24] already zeros
+	// Project ID (GUID) - zeros; header[8:24] already zeroed
 
-	// Version Major = 1, Minor = 2
-	header[24] = 1
-	header[25] = 2
+	header[24] = w.version.Major
+	header[25] = w.version.Minor
 
-	// System Identifier (32 bytes)
 	copy(header[26:58], []byte("CompactMapper"))
-
-	// Generating Software (32 bytes)
 	copy(header[58:90], []byte("CompactMapper v1.0"))
 
-	// File Creation Day of Year & Year
 	now := time.Now()
-	dayOfYear := now.YearDay()
-	binary.LittleEndian.PutUint16(header[90:92], uint16(dayOfYear))
+	binary.LittleEndian.PutUint16(header[90:92], uint16(now.YearDay()))
 	binary.LittleEndian.PutUint16(header[92:94], uint16(now.Year()))
 
-	// Header size
-	binary.LittleEndian.PutUint16(header[94:96], 227)
-
-	// Offset to point data
-	binary.LittleEndian.PutUint32(header[96:100], 227)
+	binary.LittleEndian.PutUint16(header[94:96], headerSize)
+	binary.LittleEndian.PutUint32(header[96:100], uint32(headerSize))
 
 	// Number of Variable Length Records
 	binary.LittleEndian.PutUint32(header[100:104], 0)
 
-	// Point Data Format ID (3 = XYZ + Intensity + GPS Time + RGB)
-	header[104] = 3
-
-	// Point Data Record Length (34 bytes for format 3)
-	binary.LittleEndian.PutUint16(header[105:107], 34)
-
-	// Number of point records
-	binary.LittleEndian.PutUint32(header[107:111], uint32(len(w.points)))
-
-	// Number of points by return (5 fields)
-	// We'll put all points in return 1
-	binary.LittleEndian.PutUint32(header[111:115], uint32(len(w.points)))
+	header[104] = w.pointFormat
+	binary.LittleEndian.PutUint16(header[105:107], recordLength)
+}
 
-	// Scale factors (0.001 for better precision)
-	xScale := 0.001
-	yScale := 0.001
-	zScale := 0.001
+// writeScaleOffsetBounds fills in the scale/offset/bounds fields, which
+// sit at the same byte offsets (131-227) in both the 1.2 and 1.4 header
+// layouts.
+func (w *Writer) writeScaleOffsetBounds(header []byte, xScale, yScale, zScale float64) {
 	binary.LittleEndian.PutUint64(header[131:139], math.Float64bits(xScale))
 	binary.LittleEndian.PutUint64(header[139:147], math.Float64bits(yScale))
 	binary.LittleEndian.PutUint64(header[147:155], math.Float64bits(zScale))
 
-	// Offsets
 	binary.LittleEndian.PutUint64(header[155:163], math.Float64bits(w.minX))
 	binary.LittleEndian.PutUint64(header[163:171], math.Float64bits(w.minY))
 	binary.LittleEndian.PutUint64(header[171:179], math.Float64bits(w.minZ))
 
-	// Max X, Y, Z
 	binary.LittleEndian.PutUint64(header[179:187], math.Float64bits(w.maxX))
 	binary.LittleEndian.PutUint64(header[187:195], math.Float64bits(w.maxY))
 	binary.LittleEndian.PutUint64(header[195:203], math.Float64bits(w.maxZ))
 
-	// Min X, Y, Z
 	binary.LittleEndian.PutUint64(header[203:211], math.Float64bits(w.minX))
 	binary.LittleEndian.PutUint64(header[211:219], math.Float64bits(w.minY))
 	binary.LittleEndian.PutUint64(header[219:227], math.Float64bits(w.minZ))
+}
 
-	// Write header
-	if _, err := file.Write(header); err != nil {
-		return err
-	}
-
-	// Write point data (Format 3: 34 bytes per point)
-	for _, p := range w.points {
-		pointData := make([]byte, 34)
-
-		// X, Y, Z as scaled integers
-		x := int32((p.X - w.minX) / xScale)
-		y := int32((p.Y - w.minY) / yScale)
-		z := int32((p.Z - w.minZ) / zScale)
-
-		binary.LittleEndian.PutUint32(pointData[0:4], uint32(x))
-		binary.LittleEndian.PutUint32(pointData[4:8], uint32(y))
-		binary.LittleEndian.PutUint32(pointData[8:12], uint32(z))
-
-		// Intensity
-		binary.LittleEndian.PutUint16(pointData[12:14], p.Intensity)
-
-		// Return number, number of returns, scan direction, edge of flight line
-		pointData[14] = 0x01 // First return of 1
-
-		// Classification
-		pointData[15] = p.Classification
-
-		// Scan angle rank
-		pointData[16] = 0
-
-		// User data
-		pointData[17] = 0
+// scaledXYZ converts a point's real-world coordinates to the int32
+// triplet every point format encodes, relative to the given offset and
+// scale factors. It rounds to the nearest int32 rather than truncating,
+// so e.g. Z=5.401 at 0.001 scale quantizes to 5401, not 5400.
+func scaledXYZ(p Point, offX, offY, offZ, xScale, yScale, zScale float64) (int32, int32, int32) {
+	x := int32(math.Round((p.X - offX) / xScale))
+	y := int32(math.Round((p.Y - offY) / yScale))
+	z := int32(math.Round((p.Z - offZ) / zScale))
+	return x, y, z
+}
 
-		// Point source ID
-		binary.LittleEndian.PutUint16(pointData[18:20], 0)
+// encodePointFormat2 encodes a Format 2 record (26 bytes: XYZ + Intensity + RGB).
+func encodePointFormat2(p Point, offX, offY, offZ, xScale, yScale, zScale float64) []byte {
+	pointData := make([]byte, 26)
+	x, y, z := scaledXYZ(p, offX, offY, offZ, xScale, yScale, zScale)
+
+	binary.LittleEndian.PutUint32(pointData[0:4], uint32(x))
+	binary.LittleEndian.PutUint32(pointData[4:8], uint32(y))
+	binary.LittleEndian.PutUint32(pointData[8:12], uint32(z))
+	binary.LittleEndian.PutUint16(pointData[12:14], p.Intensity)
+	pointData[14] = 0x01 // First return of 1
+	pointData[15] = p.Classification
+	pointData[16] = 0 // Scan angle rank
+	pointData[17] = 0 // User data
+	binary.LittleEndian.PutUint16(pointData[18:20], 0)
+	binary.LittleEndian.PutUint16(pointData[20:22], p.R)
+	binary.LittleEndian.PutUint16(pointData[22:24], p.G)
+	binary.LittleEndian.PutUint16(pointData[24:26], p.B)
+
+	return pointData
+}
 
-		// GPS Time (Format 3) - 8 bytes at offset 20
-		binary.LittleEndian.PutUint64(pointData[20:28], math.Float64bits(p.GPSTime))
+// encodePointFormat3 encodes a Format 3 record (34 bytes: XYZ + Intensity + GPS Time + RGB).
+func encodePointFormat3(p Point, offX, offY, offZ, xScale, yScale, zScale float64) []byte {
+	pointData := make([]byte, 34)
+	x, y, z := scaledXYZ(p, offX, offY, offZ, xScale, yScale, zScale)
+
+	binary.LittleEndian.PutUint32(pointData[0:4], uint32(x))
+	binary.LittleEndian.PutUint32(pointData[4:8], uint32(y))
+	binary.LittleEndian.PutUint32(pointData[8:12], uint32(z))
+	binary.LittleEndian.PutUint16(pointData[12:14], p.Intensity)
+	pointData[14] = 0x01 // First return of 1
+	pointData[15] = p.Classification
+	pointData[16] = 0 // Scan angle rank
+	pointData[17] = 0 // User data
+	binary.LittleEndian.PutUint16(pointData[18:20], 0)
+	binary.LittleEndian.PutUint64(pointData[20:28], math.Float64bits(p.GPSTime))
+	binary.LittleEndian.PutUint16(pointData[28:30], p.R)
+	binary.LittleEndian.PutUint16(pointData[30:32], p.G)
+	binary.LittleEndian.PutUint16(pointData[32:34], p.B)
+
+	return pointData
+}
 
-		// RGB (Format 3) - moved to offset 28
-		binary.LittleEndian.PutUint16(pointData[28:30], p.R)
-		binary.LittleEndian.PutUint16(pointData[30:32], p.G)
-		binary.LittleEndian.PutUint16(pointData[32:34], p.B)
+// encodePointFormat6 encodes a Format 6 record (30 bytes: XYZ + Intensity
+// + extended return/classification bits + scan angle + GPS Time). This
+// is the LAS 1.4 baseline format - no RGB - used by tooling that only
+// needs geometry plus extended classification range (0-255 vs format
+// 3's 0-31).
+func encodePointFormat6(p Point, offX, offY, offZ, xScale, yScale, zScale float64) []byte {
+	pointData := make([]byte, 30)
+	x, y, z := scaledXYZ(p, offX, offY, offZ, xScale, yScale, zScale)
+
+	binary.LittleEndian.PutUint32(pointData[0:4], uint32(x))
+	binary.LittleEndian.PutUint32(pointData[4:8], uint32(y))
+	binary.LittleEndian.PutUint32(pointData[8:12], uint32(z))
+	binary.LittleEndian.PutUint16(pointData[12:14], p.Intensity)
+	pointData[14] = 0x11 // Return number 1 (bits 0-3), number of returns 1 (bits 4-7)
+	pointData[15] = 0    // Classification flags (4 bits) + scanner channel (2 bits) + scan direction/edge
+	pointData[16] = p.Classification
+	pointData[17] = 0 // User data
+	binary.LittleEndian.PutUint16(pointData[18:20], 0) // Scan angle (0.006 deg units)
+	binary.LittleEndian.PutUint16(pointData[20:22], 0) // Point source ID
+	binary.LittleEndian.PutUint64(pointData[22:30], math.Float64bits(p.GPSTime))
+
+	return pointData
+}
 
-		if _, err := file.Write(pointData); err != nil {
-			return err
-		}
-	}
+// encodePointFormat7 encodes a Format 7 record (36 bytes: Format 6 plus
+// RGB), the modern equivalent of Format 3 that tools like QGIS 3.x,
+// PDAL, and CloudCompare treat as current rather than legacy.
+func encodePointFormat7(p Point, offX, offY, offZ, xScale, yScale, zScale float64) []byte {
+	pointData := make([]byte, 36)
+	copy(pointData[0:30], encodePointFormat6(p, offX, offY, offZ, xScale, yScale, zScale))
+	binary.LittleEndian.PutUint16(pointData[30:32], p.R)
+	binary.LittleEndian.PutUint16(pointData[32:34], p.G)
+	binary.LittleEndian.PutUint16(pointData[34:36], p.B)
+
+	return pointData
+}
 
-	return nil
+// encodePointFormat8 encodes a Format 8 record (38 bytes: Format 7 plus a
+// near-infrared channel), for sensors that capture NIR alongside RGB.
+// Formats 9 and 10 (the waveform equivalents of 6 and 8) are deliberately
+// not implemented: they require a Wave Packet Descriptor VLR plus
+// external waveform data packet storage, and this package has no use for
+// either - nothing it produces or consumes carries waveform data.
+func encodePointFormat8(p Point, offX, offY, offZ, xScale, yScale, zScale float64) []byte {
+	pointData := make([]byte, 38)
+	copy(pointData[0:36], encodePointFormat7(p, offX, offY, offZ, xScale, yScale, zScale))
+	binary.LittleEndian.PutUint16(pointData[36:38], p.NIR)
+
+	return pointData
 }
@@ -178,3 +178,198 @@ func TestReadNonexistentFile(t *testing.T) {
 		t.Error("Expected error for nonexistent file, got nil")
 	}
 }
+
+// TestSetFormatRejectsUnsupportedCombination verifies version/format
+// pairs outside the supported set are rejected up front.
+func TestSetFormatRejectsUnsupportedCombination(t *testing.T) {
+	writer := NewWriter()
+	if err := writer.SetFormat(Version{1, 4}, 3); err == nil {
+		t.Error("expected an error for LAS 1.4 point format 3, got nil")
+	}
+	if err := writer.SetFormat(Version{1, 2}, 7); err == nil {
+		t.Error("expected an error for LAS 1.2 point format 7, got nil")
+	}
+	if err := writer.SetFormat(Version{1, 4}, 7); err != nil {
+		t.Errorf("expected LAS 1.4 point format 7 to be accepted, got: %v", err)
+	}
+}
+
+// TestWriteAndReadLAS14Format7 verifies a LAS 1.4 file (375-byte header,
+// 64-bit point count, point format 7) round-trips through Writer/Reader.
+func TestWriteAndReadLAS14Format7(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "test14.las")
+
+	testPoints := []Point{
+		{X: 100.5, Y: 200.3, Z: 5.2, R: 65535, G: 0, B: 0, Intensity: 100, Classification: 1, GPSTime: 1727776202.800},
+		{X: 100.6, Y: 200.4, Z: 5.3, R: 0, G: 65535, B: 0, Intensity: 150, Classification: 2, GPSTime: 1727776203.000},
+	}
+
+	writer := NewWriter()
+	if err := writer.SetFormat(Version{1, 4}, 7); err != nil {
+		t.Fatalf("SetFormat failed: %v", err)
+	}
+	for _, pt := range testPoints {
+		writer.AddPoint(pt)
+	}
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expectedSize := int64(375 + len(testPoints)*36)
+	info, err := os.Stat(lasFile)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != expectedSize {
+		t.Errorf("File size = %d, want %d (375 header + %d * 36)", info.Size(), expectedSize, len(testPoints))
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	header := reader.GetHeader()
+	if header.VersionMajor != 1 || header.VersionMinor != 4 {
+		t.Errorf("Version = %d.%d, want 1.4", header.VersionMajor, header.VersionMinor)
+	}
+	if header.PointFormat != 7 {
+		t.Errorf("Point format = %d, want 7", header.PointFormat)
+	}
+	if header.PointRecordLength != 36 {
+		t.Errorf("Point record length = %d, want 36", header.PointRecordLength)
+	}
+	if header.PointCount != uint32(len(testPoints)) {
+		t.Errorf("Point count = %d, want %d", header.PointCount, len(testPoints))
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != len(testPoints) {
+		t.Fatalf("Read %d points, expected %d", len(points), len(testPoints))
+	}
+
+	tolerance := 0.01
+	for i, got := range points {
+		want := testPoints[i]
+		if math.Abs(got.X-want.X) > tolerance || math.Abs(got.Y-want.Y) > tolerance || math.Abs(got.Z-want.Z) > tolerance {
+			t.Errorf("Point %d: XYZ = (%f,%f,%f), want (%f,%f,%f)", i, got.X, got.Y, got.Z, want.X, want.Y, want.Z)
+		}
+		if got.R != want.R || got.G != want.G || got.B != want.B {
+			t.Errorf("Point %d: RGB = (%d,%d,%d), want (%d,%d,%d)", i, got.R, got.G, got.B, want.R, want.G, want.B)
+		}
+		if got.Classification != want.Classification {
+			t.Errorf("Point %d: Classification = %d, want %d", i, got.Classification, want.Classification)
+		}
+		if math.Abs(got.GPSTime-want.GPSTime) > 0.001 {
+			t.Errorf("Point %d: GPSTime = %f, want %f", i, got.GPSTime, want.GPSTime)
+		}
+	}
+}
+
+// TestWriteAndReadLAS14Format8 verifies point format 8 (Format 7 plus a
+// near-infrared band) round-trips through Writer/Reader, including NIR.
+func TestWriteAndReadLAS14Format8(t *testing.T) {
+	tmpDir := t.TempDir()
+	lasFile := filepath.Join(tmpDir, "test14-format8.las")
+
+	testPoints := []Point{
+		{X: 100.5, Y: 200.3, Z: 5.2, R: 65535, G: 0, B: 0, NIR: 12345, Intensity: 100, Classification: 1, GPSTime: 1727776202.800},
+		{X: 100.6, Y: 200.4, Z: 5.3, R: 0, G: 65535, B: 0, NIR: 54321, Intensity: 150, Classification: 2, GPSTime: 1727776203.000},
+	}
+
+	writer := NewWriter()
+	if err := writer.SetFormat(Version{1, 4}, 8); err != nil {
+		t.Fatalf("SetFormat failed: %v", err)
+	}
+	for _, pt := range testPoints {
+		writer.AddPoint(pt)
+	}
+	if err := writer.Write(lasFile); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expectedSize := int64(375 + len(testPoints)*38)
+	info, err := os.Stat(lasFile)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != expectedSize {
+		t.Errorf("File size = %d, want %d (375 header + %d * 38)", info.Size(), expectedSize, len(testPoints))
+	}
+
+	reader, err := NewReader(lasFile)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	header := reader.GetHeader()
+	if header.PointFormat != 8 {
+		t.Errorf("Point format = %d, want 8", header.PointFormat)
+	}
+	if header.PointRecordLength != 38 {
+		t.Errorf("Point record length = %d, want 38", header.PointRecordLength)
+	}
+	if header.HeaderSize != 375 {
+		t.Errorf("HeaderSize = %d, want 375", header.HeaderSize)
+	}
+
+	points, err := reader.ReadPoints()
+	if err != nil {
+		t.Fatalf("ReadPoints failed: %v", err)
+	}
+	if len(points) != len(testPoints) {
+		t.Fatalf("Read %d points, expected %d", len(points), len(testPoints))
+	}
+	for i, got := range points {
+		want := testPoints[i]
+		if got.NIR != want.NIR {
+			t.Errorf("Point %d: NIR = %d, want %d", i, got.NIR, want.NIR)
+		}
+		if got.R != want.R || got.G != want.G || got.B != want.B {
+			t.Errorf("Point %d: RGB = (%d,%d,%d), want (%d,%d,%d)", i, got.R, got.G, got.B, want.R, want.G, want.B)
+		}
+	}
+}
+
+// TestSetCompressionLAZDelegatesToWriteLAZ verifies Write honors
+// SetCompression(CompressionLAZ) by routing through the same WriteLAZ
+// path (and surfacing the same error) as calling WriteLAZ directly.
+func TestSetCompressionLAZDelegatesToWriteLAZ(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tmpDir := t.TempDir()
+	lazFile := filepath.Join(tmpDir, "test.laz")
+
+	writer := NewWriter()
+	writer.SetCompression(CompressionLAZ)
+	writer.AddPoint(Point{X: 1, Y: 2, Z: 3})
+
+	err := writer.Write(lazFile)
+	if err == nil {
+		t.Fatal("expected an error when no LAZ compressor is available, got nil")
+	}
+}
+
+// TestWriteLAZWithoutToolsFails verifies WriteLAZ surfaces a clear error
+// when neither laszip nor pdal is on PATH, rather than silently writing
+// an uncompressed file under a .laz name.
+func TestWriteLAZWithoutToolsFails(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	tmpDir := t.TempDir()
+	lazFile := filepath.Join(tmpDir, "test.laz")
+
+	writer := NewWriter()
+	writer.AddPoint(Point{X: 1, Y: 2, Z: 3})
+
+	err := writer.WriteLAZ(lazFile)
+	if err == nil {
+		t.Fatal("expected an error when no LAZ compressor is available, got nil")
+	}
+}
@@ -3,8 +3,16 @@
 package test
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -55,13 +63,13 @@ func TestFullPipeline(t *testing.T) {
 	}
 
 	// Step 2: Convert sorted CSVs to LAS
-	count, err := converter.ConvertDirectory(sortedDir, lasDir)
+	results, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, converter.ConvertOptions{})
 	if err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
 
-	if count != 2 {
-		t.Errorf("Expected 2 files converted, got %d", count)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 files converted, got %d", len(results))
 	}
 
 	// Verify LAS files exist and are valid
@@ -353,7 +361,7 @@ func TestLASFormatValidation(t *testing.T) {
 		t.Fatalf("Sorting failed: %v", err)
 	}
 
-	if _, err := converter.ConvertDirectory(sortedDir, lasDir); err != nil {
+	if _, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, converter.ConvertOptions{}); err != nil {
 		t.Fatalf("Conversion failed: %v", err)
 	}
 
@@ -423,6 +431,98 @@ func TestLASFormatValidation(t *testing.T) {
 	}
 }
 
+// TestLASExtraBytesValidation validates ConvertDirectory's ExtraBytes
+// option end to end: the written LAS 1.4 format 7 files carry a
+// readable Extra Bytes VLR, every point's compaction telemetry round-
+// trips through it, and a "?" cell (CAT-roller's missing-value sentinel)
+// reads back as NaN rather than silently becoming 0.
+func TestLASExtraBytesValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	sortedDir := filepath.Join(tmpDir, "sorted")
+	lasDir := filepath.Join(tmpDir, "las")
+
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,LastRadioLtncy,DesignName,Task,MeasuredData,Machine,Speed,LastGPSMode,GPSAccTol,TargPassCount,TotalPasses,Lift,LastCMV,TargCMV,LastEVIB1,TargEVIB1,LastEVIB2,TargEVIB2,LastMDP,TargMDP,LastRMV,LastFreq,LastAmp,TargThickness,MachineGear,VibeState,LastTemp
+2025/Oct/01 09:00:00.000,1000.0,2000.0,10.0,2,10,Design1,?,Data1,M1,1.0km/h,RTK Fixed,Coarse (0.100),4,2,?,45.2,50.0,?,?,?,?,30.1,50.0,?,?,0.97,?,Forward,Off,18.5
+2025/Oct/01 09:01:00.000,1001.0,2001.0,10.1,4,10,Design1,?,Data1,M1,1.0km/h,RTK Fixed,Coarse (0.100),4,4,?,?,?,?,?,?,?,?,50.0,?,?,0.97,?,Forward,Off,?
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sorter.SortCSV(testCSV, sortedDir, false, nil); err != nil {
+		t.Fatalf("Sorting failed: %v", err)
+	}
+
+	opts := converter.ConvertOptions{
+		LASFormat:  converter.LASFormat{Version: las.Version{Major: 1, Minor: 4}, PointFormat: 7},
+		ExtraBytes: true,
+	}
+	if _, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, opts); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	lasFiles, err := filepath.Glob(filepath.Join(lasDir, "*.las"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lasFiles) == 0 {
+		t.Fatal("No LAS files created")
+	}
+
+	for _, lasPath := range lasFiles {
+		reader, err := las.NewReader(lasPath)
+		if err != nil {
+			t.Fatalf("Failed to read LAS file %s: %v", filepath.Base(lasPath), err)
+		}
+
+		header := reader.GetHeader()
+		if header.VersionMajor != 1 || header.VersionMinor != 4 {
+			t.Errorf("LAS version: got %d.%d, want 1.4", header.VersionMajor, header.VersionMinor)
+		}
+		if header.PointFormat != 7 {
+			t.Errorf("Point format: got %d, want 7", header.PointFormat)
+		}
+
+		points, err := reader.ReadPoints()
+		reader.Close()
+		if err != nil {
+			t.Fatalf("Failed to read points: %v", err)
+		}
+		if len(points) == 0 {
+			t.Fatal("LAS file has no points")
+		}
+
+		for i, pt := range points {
+			if pt.Extra == nil {
+				t.Errorf("point %d: Extra is nil, want decoded compaction telemetry", i)
+				continue
+			}
+			if math.IsNaN(pt.Extra.PassCount) {
+				t.Errorf("point %d: PassCount = NaN, want a recorded value", i)
+			}
+		}
+
+		// The second row's LastCMV, TargEVIB1/TargEVIB2, and LastTemp
+		// cells are "?" - verify at least one of those columns reads
+		// back as NaN instead of a silent 0.
+		foundMissing := false
+		for _, pt := range points {
+			if pt.Extra != nil && math.IsNaN(pt.Extra.LastCMV) {
+				foundMissing = true
+				break
+			}
+		}
+		if !foundMissing {
+			t.Error(`expected at least one point's LastCMV to be NaN (from a "?" cell), got none`)
+		}
+	}
+}
+
 // TestErrorHandling validates error handling for invalid CSV input
 func TestErrorHandling(t *testing.T) {
 	if testing.Short() {
@@ -479,6 +579,99 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+// TestBundleDirectory validates that converter.BundleDirectory packages a
+// sort+convert run's outputs into one deterministic tar archive whose
+// manifest.json matches the LAS entries it describes.
+func TestBundleDirectory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	sortedDir := filepath.Join(tmpDir, "sorted")
+	lasDir := filepath.Join(tmpDir, "las")
+
+	testCSV := filepath.Join(tmpDir, "test.csv")
+	csvContent := `Time,CellN_m,CellE_m,Elevation_m,PassCount,LastRadioLtncy,DesignName,Task,MeasuredData,Machine,Speed,LastGPSMode,GPSAccTol,TargPassCount,TotalPasses,Lift,LastCMV,TargCMV,LastEVIB1,TargEVIB1,LastEVIB2,TargEVIB2,LastMDP,TargMDP,LastRMV,LastFreq,LastAmp,TargThickness,MachineGear,VibeState,LastTemp
+2025/Oct/01 09:30:02.800,2031878.930,91550.610,5.372,2,10,Design1,?,Data1,Machine1,0.8km/h,RTK Fixed,Coarse (0.100),4,2,?,?,50.0,?,?,?,?,?,50.0,?,?,0.97,?,Reverse,Off,?
+2025/Oct/01 09:22:49.600,2031879.270,91550.610,5.401,4,10,Design1,?,Data1,Machine1,0.9km/h,RTK Fixed,Coarse (0.100),4,2,?,?,50.0,?,?,?,?,?,50.0,?,?,0.97,?,Reverse,Off,?
+2025/Oct/01 09:22:49.700,2031879.610,91550.610,5.387,5,10,Design2,?,Data2,Machine2,0.9km/h,RTK Fixed,Coarse (0.100),4,2,?,?,50.0,?,?,?,?,?,50.0,?,?,2.10,?,Reverse,Off,?
+`
+	if err := os.WriteFile(testCSV, []byte(csvContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sorter.SortCSV(testCSV, sortedDir, false, nil); err != nil {
+		t.Fatalf("Sorting failed: %v", err)
+	}
+	if _, err := converter.ConvertDirectory(context.Background(), sortedDir, lasDir, converter.ConvertOptions{}); err != nil {
+		t.Fatalf("Conversion failed: %v", err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := converter.BundleDirectory(sortedDir, lasDir, &archive)
+	if err != nil {
+		t.Fatalf("BundleDirectory failed: %v", err)
+	}
+	if len(manifest.Groups) != 2 {
+		t.Fatalf("manifest has %d groups, want 2", len(manifest.Groups))
+	}
+
+	shaByMember := make(map[string]string, len(manifest.Groups))
+	for _, g := range manifest.Groups {
+		shaByMember["las/"+g.Date+"design"+g.DesignName+"amp"+g.Amp+".las"] = g.SHA256
+	}
+
+	tr := tar.NewReader(bytes.NewReader(archive.Bytes()))
+	members := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error reading tar entry: %v", err)
+		}
+		members[hdr.Name] = true
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("error reading tar data for %s: %v", hdr.Name, err)
+		}
+
+		if hdr.Name == "manifest.json" {
+			var decoded converter.ArchiveManifest
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("manifest.json does not parse: %v", err)
+			}
+			continue
+		}
+
+		if wantSHA, ok := shaByMember[hdr.Name]; ok {
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != wantSHA {
+				t.Errorf("%s: sha256 = %x, manifest says %s", hdr.Name, sum, wantSHA)
+			}
+		}
+	}
+
+	wantMembers := map[string]bool{
+		"manifest.json": true,
+		"sorted/2025-10-01designDesign1amp097.csv": true,
+		"sorted/2025-10-01designDesign2amp210.csv": true,
+		"las/2025-10-01designDesign1amp097.las":    true,
+		"las/2025-10-01designDesign2amp210.las":    true,
+	}
+	if len(members) != len(wantMembers) {
+		t.Errorf("archive has %d members, want %d: %v", len(members), len(wantMembers), members)
+	}
+	for name := range members {
+		if !wantMembers[name] {
+			t.Errorf("unexpected archive member: %s", name)
+		}
+	}
+}
+
 // Helper function to count CSV rows
 func countCSVRows(filename string) (int, error) {
 	file, err := os.Open(filename)